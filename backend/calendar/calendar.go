@@ -0,0 +1,164 @@
+package calendar
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:embed holidays.txt
+var bundledHolidays string
+
+const dateLayout = "2006-01-02"
+
+var (
+	mu       sync.RWMutex
+	holidays = map[string]bool{}
+	// 休市表覆盖的年份，超出范围时只按周末判断并提示一次
+	years  = map[int]bool{}
+	warned = map[int]bool{}
+)
+
+func init() {
+	if err := load(strings.NewReader(bundledHolidays)); err != nil {
+		log.Printf("load bundled holidays failed: %v", err)
+	}
+}
+
+// LoadFile 追加加载休市日文件（格式同内置 holidays.txt），用于年度安排公布后更新日历
+func LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return load(f)
+}
+
+func load(r io.Reader) error {
+	parsed := []time.Time{}
+	sc := bufio.NewScanner(r)
+	line := 0
+	for sc.Scan() {
+		line++
+		s := sc.Text()
+		if i := strings.Index(s, "#"); i >= 0 {
+			s = s[:i]
+		}
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		t, err := time.Parse(dateLayout, s)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid date %q", line, s)
+		}
+		parsed = append(parsed, t)
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for _, t := range parsed {
+		holidays[t.Format(dateLayout)] = true
+		years[t.Year()] = true
+	}
+	return nil
+}
+
+// Holidays 返回已加载的工作日休市日（升序）
+func Holidays() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	out := make([]string, 0, len(holidays))
+	for d := range holidays {
+		out = append(out, d)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Covers 休市表是否覆盖该年份（未覆盖的年份只能按周末判断）
+func Covers(year int) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return years[year]
+}
+
+// IsTradingDay 判断 t 所在日期（按 t 自身的时区取年月日）是否为交易日
+func IsTradingDay(t time.Time) bool {
+	if wd := t.Weekday(); wd == time.Saturday || wd == time.Sunday {
+		return false
+	}
+	mu.RLock()
+	holiday := holidays[t.Format(dateLayout)]
+	covered := years[t.Year()]
+	mu.RUnlock()
+	if !covered {
+		mu.Lock()
+		if !warned[t.Year()] {
+			warned[t.Year()] = true
+			log.Printf("calendar: no holiday table for %d, treating all weekdays as trading days", t.Year())
+		}
+		mu.Unlock()
+	}
+	return !holiday
+}
+
+// IsTradingDate 同 IsTradingDay，参数为 YYYY-MM-DD
+func IsTradingDate(date string) bool {
+	t, err := time.Parse(dateLayout, date)
+	if err != nil {
+		return false
+	}
+	return IsTradingDay(t)
+}
+
+// NextTradingDay 返回 t 之后（不含当天）的第一个交易日，时分秒与 t 相同
+func NextTradingDay(t time.Time) time.Time {
+	d := t.AddDate(0, 0, 1)
+	for !IsTradingDay(d) {
+		d = d.AddDate(0, 0, 1)
+	}
+	return d
+}
+
+// PrevTradingDay 返回 t 之前（不含当天）的最后一个交易日，时分秒与 t 相同
+func PrevTradingDay(t time.Time) time.Time {
+	d := t.AddDate(0, 0, -1)
+	for !IsTradingDay(d) {
+		d = d.AddDate(0, 0, -1)
+	}
+	return d
+}
+
+// TradingDays 返回 [from, to] 闭区间内的交易日（YYYY-MM-DD）
+func TradingDays(from, to time.Time) []string {
+	out := []string{}
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		if IsTradingDay(d) {
+			out = append(out, d.Format(dateLayout))
+		}
+	}
+	return out
+}
+
+// CountTradingDays 返回 (from, to] 区间内的交易日数
+func CountTradingDays(from, to time.Time) int {
+	n := 0
+	for d := from.AddDate(0, 0, 1); !d.After(to); d = d.AddDate(0, 0, 1) {
+		if IsTradingDay(d) {
+			n++
+		}
+	}
+	return n
+}
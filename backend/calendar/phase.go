@@ -0,0 +1,62 @@
+package calendar
+
+import "time"
+
+// Phase 交易时段
+type Phase string
+
+const (
+	PhaseClosed       Phase = "closed"        // 休市（非交易日或收盘后）
+	PhasePreOpen      Phase = "pre_open"      // 开盘集合竞价前 / 9:25-9:30 撮合结果公布后
+	PhaseCallAuction  Phase = "call_auction"  // 开盘集合竞价 9:15-9:25
+	PhaseMorning      Phase = "morning"       // 上午连续竞价 9:30-11:30
+	PhaseLunch        Phase = "lunch"         // 午间休市 11:30-13:00
+	PhaseAfternoon    Phase = "afternoon"     // 下午连续竞价 13:00-14:57
+	PhaseCloseAuction Phase = "close_auction" // 收盘集合竞价 14:57-15:00
+)
+
+// hm 把时分转换为当天分钟数
+func hm(h, m int) int { return h*60 + m }
+
+// PhaseAt 返回 t 所处的交易时段（按 t 自身的时区取时分）
+func PhaseAt(t time.Time) Phase {
+	if !IsTradingDay(t) {
+		return PhaseClosed
+	}
+	m := hm(t.Hour(), t.Minute())
+	switch {
+	case m < hm(9, 0):
+		return PhaseClosed
+	case m < hm(9, 15):
+		return PhasePreOpen
+	case m < hm(9, 25):
+		return PhaseCallAuction
+	case m < hm(9, 30):
+		return PhasePreOpen
+	case m < hm(11, 30):
+		return PhaseMorning
+	case m < hm(13, 0):
+		return PhaseLunch
+	case m < hm(14, 57):
+		return PhaseAfternoon
+	case m < hm(15, 0):
+		return PhaseCloseAuction
+	default:
+		return PhaseClosed
+	}
+}
+
+// IsTradingTime 是否处于有成交的时段（集合竞价与连续竞价）
+func IsTradingTime(t time.Time) bool {
+	switch PhaseAt(t) {
+	case PhaseCallAuction, PhaseMorning, PhaseAfternoon, PhaseCloseAuction:
+		return true
+	}
+	return false
+}
+
+// IsContinuousTrading 是否处于连续竞价时段
+func IsContinuousTrading(t time.Time) bool {
+	p := PhaseAt(t)
+	return p == PhaseMorning || p == PhaseAfternoon
+}
@@ -0,0 +1,74 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Shanghai A 股市场时区；系统缺少时区数据库时退化为固定的 UTC+8
+var Shanghai = loadShanghai()
+
+func loadShanghai() *time.Location {
+	if loc, err := time.LoadLocation("Asia/Shanghai"); err == nil {
+		return loc
+	}
+	return time.FixedZone("CST", 8*3600)
+}
+
+// Clock 市场时钟。所有返回的时间都在 Shanghai 时区，
+// 调度器、行情轮询与交易时段判断都通过它取时间，便于替换为模拟时钟。
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+// realClock 系统时钟
+type realClock struct{}
+
+func (realClock) Now() time.Time        { return time.Now().In(Shanghai) }
+func (realClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+// Real 返回系统时钟
+func Real() Clock { return realClock{} }
+
+var (
+	mu      sync.RWMutex
+	current Clock = realClock{}
+)
+
+// Set 替换全局时钟（如换成 SimClock），nil 恢复为系统时钟
+func Set(c Clock) {
+	mu.Lock()
+	defer mu.Unlock()
+	if c == nil {
+		c = realClock{}
+	}
+	current = c
+}
+
+// Current 返回全局时钟
+func Current() Clock {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Now 全局时钟的当前时间（Shanghai 时区）
+func Now() time.Time { return Current().Now() }
+
+// Sleep 按全局时钟休眠
+func Sleep(d time.Duration) { Current().Sleep(d) }
+
+// Today 当前交易所日期 YYYY-MM-DD
+func Today() string { return Now().Format("2006-01-02") }
+
+// Date 返回 Shanghai 时区 t 所在日期的零点
+func Date(t time.Time) time.Time {
+	t = t.In(Shanghai)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, Shanghai)
+}
+
+// ParseDate 按 Shanghai 时区解析 YYYY-MM-DD
+func ParseDate(s string) (time.Time, error) {
+	return time.ParseInLocation("2006-01-02", s, Shanghai)
+}
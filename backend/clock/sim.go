@@ -0,0 +1,82 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// SimClock 模拟时钟：从 start 开始按 speed 倍速流逝，并可通过 Advance 直接跳过一段时间，
+// 用于在演示或测试中驱动一个完整的（过去的）交易日。
+type SimClock struct {
+	mu        sync.Mutex
+	base      time.Time // 最近一次重置时的模拟时间
+	realBase  time.Time // 对应的真实时间
+	speed     float64
+	advanceCh chan struct{} // Advance 时关闭以唤醒 Sleep
+}
+
+// NewSimClock 创建模拟时钟，speed <= 0 时按 1 倍速
+func NewSimClock(start time.Time, speed float64) *SimClock {
+	if speed <= 0 {
+		speed = 1
+	}
+	return &SimClock{base: start.In(Shanghai), realBase: time.Now(), speed: speed, advanceCh: make(chan struct{})}
+}
+
+func (s *SimClock) nowLocked() time.Time {
+	elapsed := time.Since(s.realBase)
+	return s.base.Add(time.Duration(float64(elapsed) * s.speed))
+}
+
+// Now 当前模拟时间
+func (s *SimClock) Now() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.nowLocked()
+}
+
+// Speed 返回倍速
+func (s *SimClock) Speed() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.speed
+}
+
+// Advance 把模拟时间向前拨 d，并唤醒正在 Sleep 的协程重新判断
+func (s *SimClock) Advance(d time.Duration) {
+	s.mu.Lock()
+	s.base = s.nowLocked().Add(d)
+	s.realBase = time.Now()
+	close(s.advanceCh)
+	s.advanceCh = make(chan struct{})
+	s.mu.Unlock()
+}
+
+// Set 把模拟时间设置为 t
+func (s *SimClock) Set(t time.Time) {
+	s.mu.Lock()
+	d := t.Sub(s.nowLocked())
+	s.mu.Unlock()
+	s.Advance(d)
+}
+
+// Sleep 休眠到模拟时间经过 d；期间若被 Advance 越过目标时间则立即返回
+func (s *SimClock) Sleep(d time.Duration) {
+	target := s.Now().Add(d)
+	for {
+		s.mu.Lock()
+		remain := target.Sub(s.nowLocked())
+		ch := s.advanceCh
+		speed := s.speed
+		s.mu.Unlock()
+		if remain <= 0 {
+			return
+		}
+		timer := time.NewTimer(time.Duration(float64(remain) / speed))
+		select {
+		case <-timer.C:
+		case <-ch:
+			timer.Stop()
+		}
+	}
+}
@@ -9,23 +9,73 @@ import (
 
 type StrategyConfig struct {
 	Name    string                 `yaml:"name"`
+	// 结果表中的策略名；同名策略配置多条（不同 expr / period）时用它区分
+	Label   string                 `yaml:"label"`
 	Enabled bool                   `yaml:"enabled"`
+	// K 线周期：day（默认）| week | month
+	Period  string                 `yaml:"period"`
 	Params  map[string]interface{} `yaml:"params"`
 }
 
+// ResultName 写入 results 表的策略名：优先 label，否则为 name（非日线追加 _week / _month）
+func (sc StrategyConfig) ResultName() string {
+	if sc.Label != "" {
+		return sc.Label
+	}
+	if sc.Period != "" && sc.Period != "day" {
+		return sc.Name + "_" + sc.Period
+	}
+	return sc.Name
+}
+
 type Config struct {
 	DBPath        string           `yaml:"db_path"`
 	KLineDays     int              `yaml:"kline_days"`
 	UpdateHour    int              `yaml:"update_hour"`
 	UpdateMinute  int              `yaml:"update_minute"`
 	Combination   string           `yaml:"combination"`
+	// 策略计算使用的复权方式：""（不复权）| qfq | hfq
+	Adjust        string           `yaml:"adjust"`
 	Strategies    []StrategyConfig `yaml:"strategies"`
 	// Worker pool settings for startup watchlist KLine fetch
+	// (worker_retries / worker_backoff_ms 仅作为 upstream_retries / upstream_backoff_ms 的回退值)
 	WorkerConcurrency  int `yaml:"worker_concurrency"`
 	WorkerRetries      int `yaml:"worker_retries"`
 	WorkerDelayMs      int `yaml:"worker_delay_ms"`
 	WorkerBackoffMs    int `yaml:"worker_backoff_ms"`
 	WatchlistKlineDays int `yaml:"watchlist_kline_days"`
+	// 自选股分钟 K 线抓取周期（分钟数，如 5/15/30/60）与每次抓取条数
+	MinuteScales   []int `yaml:"minute_scales"`
+	MinuteKLineLen int   `yaml:"minute_kline_len"`
+	// 基准指数：日线与个股一起入库（kline 表），Benchmark 为策略/报告默认基准
+	BenchmarkIndexes []string `yaml:"benchmark_indexes"`
+	Benchmark        string   `yaml:"benchmark"`
+	// 市场时钟：real（默认，系统时间换算为 Asia/Shanghai）| sim（从 sim_start 开始按 sim_speed 倍速运行）
+	ClockMode string  `yaml:"clock_mode"`
+	SimStart  string  `yaml:"sim_start"`
+	SimSpeed  float64 `yaml:"sim_speed"`
+	// 额外的休市日文件（格式同 calendar/holidays.txt），用于补充内置交易日历
+	CalendarFile string `yaml:"calendar_file"`
+	// 行情数据源（sina / aktools，见 datasource 包），为空时使用 sina
+	DataSource string `yaml:"data_source"`
+	// 历史日线回补使用的数据源，为空时与 data_source 相同
+	HistorySource string `yaml:"history_source"`
+	// AKTools 服务地址（history_source/data_source 为 aktools 时使用）
+	AKToolsURL string `yaml:"aktools_url"`
+	// 通达信安装目录（或其 vipdoc 目录），history_source 为 tdx 或导入本地日线时使用
+	TDXDir string `yaml:"tdx_dir"`
+	// 上游 HTTP 模式：live（默认）| record（录制原始响应）| replay（离线回放）
+	HTTPMode       string `yaml:"http_mode"`
+	HTTPArchiveDir string `yaml:"http_archive_dir"`
+	// 上游访问控制（upstream 包）：全局限速、单 host 并发、重试退避与熔断
+	UpstreamRate             float64 `yaml:"upstream_rate"`
+	UpstreamBurst            int     `yaml:"upstream_burst"`
+	UpstreamHostConcurrency  int     `yaml:"upstream_host_concurrency"`
+	UpstreamRetries          int     `yaml:"upstream_retries"`
+	UpstreamBackoffMs        int     `yaml:"upstream_backoff_ms"`
+	UpstreamTimeoutMs        int     `yaml:"upstream_timeout_ms"`
+	UpstreamBreakerThreshold int     `yaml:"upstream_breaker_threshold"`
+	UpstreamBreakerCooldownS int     `yaml:"upstream_breaker_cooldown_s"`
 }
 
 var Cfg Config
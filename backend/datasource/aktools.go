@@ -0,0 +1,323 @@
+package datasource
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"go-stock-analyzer/backend/clock"
+	"go-stock-analyzer/backend/config"
+	"go-stock-analyzer/backend/storage"
+	"go-stock-analyzer/backend/upstream"
+)
+
+// DefaultAKToolsURL AKTools 默认监听地址（python -m aktools）
+const DefaultAKToolsURL = "http://127.0.0.1:18080"
+
+func init() {
+	Register("aktools", func() DataSource { return NewAKToolsSource(config.Cfg.AKToolsURL) })
+}
+
+// AKToolsSource 基于本地 AKTools HTTP 服务（akshare 接口）的数据源，适合历史数据回补
+type AKToolsSource struct {
+	baseURL string
+	client  *upstream.Client
+}
+
+func NewAKToolsSource(baseURL string) *AKToolsSource {
+	if baseURL == "" {
+		baseURL = DefaultAKToolsURL
+	}
+	return &AKToolsSource{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  upstreamClient(),
+	}
+}
+
+func (s *AKToolsSource) Name() string { return "aktools" }
+
+// akHistRow stock_zh_a_hist / stock_zh_a_hist_min_em 返回的行（字段为中文列名）
+type akHistRow struct {
+	Date   string  `json:"日期"`
+	Time   string  `json:"时间"`
+	Open   float64 `json:"开盘"`
+	Close  float64 `json:"收盘"`
+	High   float64 `json:"最高"`
+	Low    float64 `json:"最低"`
+	Volume float64 `json:"成交量"`
+}
+
+// akSpotRow stock_zh_a_spot_em 返回的行
+type akSpotRow struct {
+	Code      string  `json:"代码"`
+	Name      string  `json:"名称"`
+	Price     float64 `json:"最新价"`
+	PrevClose float64 `json:"昨收"`
+	Open      float64 `json:"今开"`
+	High      float64 `json:"最高"`
+	Low       float64 `json:"最低"`
+	Volume    float64 `json:"成交量"`
+}
+
+// call 请求 /api/public/{fn} 并把 JSON 结果解码到 out
+func (s *AKToolsSource) call(fn string, params url.Values, out interface{}) error {
+	u := s.baseURL + "/api/public/" + fn
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+	body, err := s.client.Get(u, nil)
+	if err != nil {
+		var se *upstream.StatusError
+		if errors.As(err, &se) {
+			return fmt.Errorf("aktools %s: status %d", fn, se.Code)
+		}
+		return err
+	}
+	return json.Unmarshal(body, out)
+}
+
+// akListRow 交易所证券列表的行：上交所/北交所用 证券代码/证券简称/上市日期，深交所用 A股代码/A股简称/A股上市日期
+type akListRow struct {
+	Code       string `json:"证券代码"`
+	Name       string `json:"证券简称"`
+	ListDate   string `json:"上市日期"`
+	SZCode     string `json:"A股代码"`
+	SZName     string `json:"A股简称"`
+	SZListDate string `json:"A股上市日期"`
+}
+
+// FetchStockList 通过各交易所证券列表获取 A 股（含上市日期），失败时退回 stock_info_a_code_name；
+// ETF 列表来自 fund_etf_spot_em（获取失败时忽略）
+func (s *AKToolsSource) FetchStockList() ([]storage.StockInfo, error) {
+	out, err := s.fetchExchangeLists()
+	if err != nil {
+		if out, err = s.fetchCodeNameList(); err != nil {
+			return nil, err
+		}
+	}
+	var etfs []struct {
+		Code string `json:"代码"`
+		Name string `json:"名称"`
+	}
+	if err := s.call("fund_etf_spot_em", nil, &etfs); err == nil {
+		for _, r := range etfs {
+			if sym := NormalizeSymbol(r.Code); sym != "" {
+				out = append(out, storage.StockInfo{Symbol: sym, Code: BareCode(sym), Name: r.Name, Market: strings.ToUpper(sym[:2])})
+			}
+		}
+	}
+	return out, nil
+}
+
+func (s *AKToolsSource) fetchExchangeLists() ([]storage.StockInfo, error) {
+	calls := []struct {
+		fn     string
+		symbol string
+	}{
+		{"stock_info_sh_name_code", "主板A股"},
+		{"stock_info_sh_name_code", "科创板"},
+		{"stock_info_sz_name_code", "A股列表"},
+		{"stock_info_bj_name_code", ""},
+	}
+	var out []storage.StockInfo
+	for _, c := range calls {
+		params := url.Values{}
+		if c.symbol != "" {
+			params.Set("symbol", c.symbol)
+		}
+		var rows []akListRow
+		if err := s.call(c.fn, params, &rows); err != nil {
+			return nil, err
+		}
+		for _, r := range rows {
+			code, name, listDate := r.Code, r.Name, r.ListDate
+			if code == "" {
+				code, name, listDate = r.SZCode, r.SZName, r.SZListDate
+			}
+			sym := NormalizeSymbol(code)
+			if sym == "" {
+				continue
+			}
+			if len(listDate) > 10 {
+				listDate = listDate[:10]
+			}
+			out = append(out, storage.StockInfo{
+				Symbol:   sym,
+				Code:     BareCode(sym),
+				Name:     name,
+				Market:   strings.ToUpper(sym[:2]),
+				ListDate: listDate,
+			})
+		}
+	}
+	return out, nil
+}
+
+func (s *AKToolsSource) fetchCodeNameList() ([]storage.StockInfo, error) {
+	var rows []struct {
+		Code string `json:"code"`
+		Name string `json:"name"`
+	}
+	if err := s.call("stock_info_a_code_name", nil, &rows); err != nil {
+		return nil, err
+	}
+	out := make([]storage.StockInfo, 0, len(rows))
+	for _, r := range rows {
+		sym := NormalizeSymbol(r.Code)
+		if sym == "" {
+			continue
+		}
+		out = append(out, storage.StockInfo{
+			Symbol: sym,
+			Code:   BareCode(sym),
+			Name:   r.Name,
+			Market: strings.ToUpper(sym[:2]),
+		})
+	}
+	return out, nil
+}
+
+// FetchDailyKLine 通过 stock_zh_a_hist 获取不复权日线（指数使用 index_zh_a_hist），只保留最近 days 条
+func (s *AKToolsSource) FetchDailyKLine(symbol string, days int) ([]storage.KLine, error) {
+	sym := NormalizeSymbol(symbol)
+	if sym == "" {
+		return nil, fmt.Errorf("invalid symbol: %s", symbol)
+	}
+	// 自然日约为交易日的 1.5 倍，多取一些再截断
+	end := clock.Now()
+	start := end.AddDate(0, 0, -(days*3/2 + 30))
+	params := url.Values{}
+	params.Set("symbol", BareCode(sym))
+	params.Set("period", "daily")
+	params.Set("start_date", start.Format("20060102"))
+	params.Set("end_date", end.Format("20060102"))
+	fn := "stock_zh_a_hist"
+	if IsIndexSymbol(sym) {
+		fn = "index_zh_a_hist"
+	} else {
+		params.Set("adjust", "")
+	}
+	var rows []akHistRow
+	if err := s.call(fn, params, &rows); err != nil {
+		return nil, err
+	}
+	klines := histRowsToKLines(sym, rows)
+	if len(klines) == 0 {
+		return nil, fmt.Errorf("empty kline for %s", sym)
+	}
+	if days > 0 && len(klines) > days {
+		klines = klines[len(klines)-days:]
+	}
+	return klines, nil
+}
+
+// FetchMinuteKLine 通过 stock_zh_a_hist_min_em 获取分钟线
+func (s *AKToolsSource) FetchMinuteKLine(symbol string, scale, datalen int) ([]storage.KLine, error) {
+	sym := NormalizeSymbol(symbol)
+	if sym == "" {
+		return nil, fmt.Errorf("invalid symbol: %s", symbol)
+	}
+	params := url.Values{}
+	params.Set("symbol", BareCode(sym))
+	params.Set("period", fmt.Sprintf("%d", scale))
+	params.Set("adjust", "")
+	var rows []akHistRow
+	if err := s.call("stock_zh_a_hist_min_em", params, &rows); err != nil {
+		return nil, err
+	}
+	klines := histRowsToKLines(sym, rows)
+	if datalen > 0 && len(klines) > datalen {
+		klines = klines[len(klines)-datalen:]
+	}
+	return klines, nil
+}
+
+// FetchQuotes 通过 stock_zh_a_spot_em 获取全市场快照后按 symbols 过滤
+func (s *AKToolsSource) FetchQuotes(symbols []string) ([]Quote, error) {
+	var rows []akSpotRow
+	if err := s.call("stock_zh_a_spot_em", nil, &rows); err != nil {
+		return nil, err
+	}
+	want := make(map[string]bool, len(symbols))
+	for _, sym := range symbols {
+		want[NormalizeSymbol(sym)] = true
+	}
+	now := clock.Now().Format("2006-01-02 15:04:05")
+	var quotes []Quote
+	for _, r := range rows {
+		sym := NormalizeSymbol(r.Code)
+		if !want[sym] {
+			continue
+		}
+		quotes = append(quotes, Quote{
+			Code:      sym,
+			Name:      r.Name,
+			Price:     r.Price,
+			PrevClose: r.PrevClose,
+			Open:      r.Open,
+			High:      r.High,
+			Low:       r.Low,
+			Volume:    int64(r.Volume * 100),
+			Time:      now,
+		})
+	}
+	return quotes, nil
+}
+
+// FetchAdjFactors 通过 stock_zh_a_daily(adjust=hfq-factor) 获取后复权因子
+func (s *AKToolsSource) FetchAdjFactors(symbol string) ([]storage.AdjFactor, error) {
+	sym := NormalizeSymbol(symbol)
+	if sym == "" {
+		return nil, fmt.Errorf("invalid symbol: %s", symbol)
+	}
+	params := url.Values{}
+	params.Set("symbol", sym)
+	params.Set("adjust", "hfq-factor")
+	var rows []struct {
+		Date   string  `json:"date"`
+		Factor float64 `json:"hfq_factor"`
+	}
+	if err := s.call("stock_zh_a_daily", params, &rows); err != nil {
+		return nil, err
+	}
+	out := make([]storage.AdjFactor, 0, len(rows))
+	for _, r := range rows {
+		if len(r.Date) < 10 || r.Factor <= 0 {
+			continue
+		}
+		out = append(out, storage.AdjFactor{Code: sym, Date: r.Date[:10], Factor: r.Factor})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Date < out[j].Date })
+	return out, nil
+}
+
+// histRowsToKLines 把 AKTools 历史行情行归一化为 storage.KLine。
+// 日线 "日期" 可能为 "2024-01-02" 或 "2024-01-02T00:00:00.000"，分钟线使用 "时间" 字段；
+// 东财成交量单位为手，这里换算成股以与新浪保持一致。
+func histRowsToKLines(symbol string, rows []akHistRow) []storage.KLine {
+	out := make([]storage.KLine, 0, len(rows))
+	for _, r := range rows {
+		date := r.Date
+		if date == "" {
+			date = r.Time
+		} else if len(date) > 10 {
+			date = date[:10]
+		}
+		if date == "" {
+			continue
+		}
+		out = append(out, storage.KLine{
+			Code:   symbol,
+			Date:   date,
+			Open:   r.Open,
+			High:   r.High,
+			Low:    r.Low,
+			Close:  r.Close,
+			Volume: r.Volume * 100,
+		})
+	}
+	return out
+}
@@ -0,0 +1,234 @@
+package datasource
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AKToolsStub 离线 AKTools 替身服务，按代码生成确定性的模拟行情，
+// 接口路径与字段与 AKTools 保持一致，便于在无网络环境下联调 AKToolsSource。
+type AKToolsStub struct {
+	stocks []struct{ Code, Name, ListDate string }
+	mux    *http.ServeMux
+}
+
+func NewAKToolsStub() *AKToolsStub {
+	s := &AKToolsStub{}
+	s.stocks = []struct{ Code, Name, ListDate string }{
+		{"000001", "平安银行", "1991-04-03"},
+		{"000002", "万科A", "1991-01-29"},
+		{"002594", "比亚迪", "2011-06-30"},
+		{"300750", "宁德时代", "2018-06-11"},
+		{"600000", "浦发银行", "1999-11-10"},
+		{"600519", "贵州茅台", "2001-08-27"},
+		{"688981", "中芯国际", "2020-07-16"},
+		{"830799", "艾融软件", "2021-11-15"},
+	}
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc("/api/public/stock_info_a_code_name", s.handleCodeName)
+	s.mux.HandleFunc("/api/public/stock_info_sh_name_code", s.handleExchangeList("sh"))
+	s.mux.HandleFunc("/api/public/stock_info_sz_name_code", s.handleExchangeList("sz"))
+	s.mux.HandleFunc("/api/public/stock_info_bj_name_code", s.handleExchangeList("bj"))
+	s.mux.HandleFunc("/api/public/fund_etf_spot_em", s.handleETFSpot)
+	s.mux.HandleFunc("/api/public/stock_zh_a_hist", s.handleHist)
+	s.mux.HandleFunc("/api/public/index_zh_a_hist", s.handleHist)
+	s.mux.HandleFunc("/api/public/stock_zh_a_hist_min_em", s.handleMinute)
+	s.mux.HandleFunc("/api/public/stock_zh_a_spot_em", s.handleSpot)
+	s.mux.HandleFunc("/api/public/stock_zh_a_daily", s.handleDaily)
+	return s
+}
+
+func (s *AKToolsStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func (s *AKToolsStub) handleCodeName(w http.ResponseWriter, r *http.Request) {
+	rows := make([]map[string]string, 0, len(s.stocks))
+	for _, st := range s.stocks {
+		rows = append(rows, map[string]string{"code": st.Code, "name": st.Name})
+	}
+	writeJSON(w, rows)
+}
+
+// handleExchangeList 模拟各交易所证券列表，列名与 akshare 一致（深交所为 A股代码 等）
+func (s *AKToolsStub) handleExchangeList(market string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		board := r.URL.Query().Get("symbol")
+		rows := []map[string]string{}
+		for _, st := range s.stocks {
+			if MarketOf(st.Code) != market {
+				continue
+			}
+			// 上交所按 主板A股 / 科创板 分开返回
+			if market == "sh" && (board == "科创板") != (st.Code[:3] == "688") {
+				continue
+			}
+			if market == "sz" {
+				rows = append(rows, map[string]string{"A股代码": st.Code, "A股简称": st.Name, "A股上市日期": st.ListDate})
+			} else {
+				rows = append(rows, map[string]string{"证券代码": st.Code, "证券简称": st.Name, "上市日期": st.ListDate})
+			}
+		}
+		writeJSON(w, rows)
+	}
+}
+
+func (s *AKToolsStub) handleETFSpot(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, []map[string]string{
+		{"代码": "510300", "名称": "沪深300ETF"},
+		{"代码": "159915", "名称": "创业板ETF"},
+	})
+}
+
+// stubBar 生成的一根模拟 K 线
+type stubBar struct {
+	t                              time.Time
+	open, high, low, close, volume float64
+}
+
+// stubSeries 以代码为种子生成 [start, end] 区间内工作日的随机游走日线
+func stubSeries(code string, start, end time.Time) []stubBar {
+	h := fnv.New64a()
+	h.Write([]byte(code))
+	rng := rand.New(rand.NewSource(int64(h.Sum64())))
+	price := 5 + rng.Float64()*50
+	// 从固定起点开始游走，保证同一天在不同请求区间内价格一致
+	day := time.Date(2015, 1, 1, 0, 0, 0, 0, time.Local)
+	var out []stubBar
+	for !day.After(end) {
+		if wd := day.Weekday(); wd != time.Saturday && wd != time.Sunday {
+			open := price
+			closep := math.Max(0.5, open*(1+(rng.Float64()-0.5)*0.06))
+			high := math.Max(open, closep) * (1 + rng.Float64()*0.02)
+			low := math.Min(open, closep) * (1 - rng.Float64()*0.02)
+			vol := float64(int(1e4 + rng.Float64()*1e5))
+			price = closep
+			if !day.Before(start) {
+				out = append(out, stubBar{t: day, open: round2(open), high: round2(high), low: round2(low), close: round2(closep), volume: vol})
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return out
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func (s *AKToolsStub) handleHist(w http.ResponseWriter, r *http.Request) {
+	code := r.URL.Query().Get("symbol")
+	if code == "" {
+		http.Error(w, `{"error":"symbol is required"}`, http.StatusBadRequest)
+		return
+	}
+	end := time.Now()
+	start := end.AddDate(-1, 0, 0)
+	if v, err := time.ParseInLocation("20060102", r.URL.Query().Get("start_date"), time.Local); err == nil {
+		start = v
+	}
+	if v, err := time.ParseInLocation("20060102", r.URL.Query().Get("end_date"), time.Local); err == nil {
+		end = v
+	}
+	seed := code
+	if strings.HasPrefix(r.URL.Path, "/api/public/index_") {
+		// 指数与同代码股票（如 000001）区分开
+		seed = "index" + code
+	}
+	bars := stubSeries(seed, start, end)
+	rows := make([]map[string]interface{}, 0, len(bars))
+	for _, b := range bars {
+		rows = append(rows, map[string]interface{}{
+			"日期":   b.t.Format("2006-01-02") + "T00:00:00.000",
+			"股票代码": code,
+			"开盘":   b.open,
+			"收盘":   b.close,
+			"最高":   b.high,
+			"最低":   b.low,
+			"成交量":  b.volume,
+		})
+	}
+	writeJSON(w, rows)
+}
+
+func (s *AKToolsStub) handleMinute(w http.ResponseWriter, r *http.Request) {
+	code := r.URL.Query().Get("symbol")
+	scale, _ := strconv.Atoi(r.URL.Query().Get("period"))
+	if code == "" || scale <= 0 {
+		http.Error(w, `{"error":"symbol and period are required"}`, http.StatusBadRequest)
+		return
+	}
+	now := time.Now()
+	bars := stubSeries(code, now.AddDate(0, 0, -7), now)
+	if len(bars) == 0 {
+		writeJSON(w, []interface{}{})
+		return
+	}
+	last := bars[len(bars)-1]
+	// 把最后一个交易日按 scale 切成上午/下午两段的分钟线
+	var rows []map[string]interface{}
+	price := last.open
+	step := (last.close - last.open) / float64(240/scale)
+	for _, session := range [][2]string{{"09:30", "11:30"}, {"13:00", "15:00"}} {
+		t0, _ := time.ParseInLocation("2006-01-02 15:04", last.t.Format("2006-01-02")+" "+session[0], time.Local)
+		t1, _ := time.ParseInLocation("2006-01-02 15:04", last.t.Format("2006-01-02")+" "+session[1], time.Local)
+		for t := t0.Add(time.Duration(scale) * time.Minute); !t.After(t1); t = t.Add(time.Duration(scale) * time.Minute) {
+			next := price + step
+			rows = append(rows, map[string]interface{}{
+				"时间":  t.Format("2006-01-02 15:04:05"),
+				"开盘":  round2(price),
+				"收盘":  round2(next),
+				"最高":  round2(math.Max(price, next)),
+				"最低":  round2(math.Min(price, next)),
+				"成交量": math.Round(last.volume / float64(240/scale)),
+			})
+			price = next
+		}
+	}
+	writeJSON(w, rows)
+}
+
+func (s *AKToolsStub) handleSpot(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	rows := make([]map[string]interface{}, 0, len(s.stocks))
+	for _, st := range s.stocks {
+		bars := stubSeries(st.Code, now.AddDate(0, 0, -7), now)
+		if len(bars) < 2 {
+			continue
+		}
+		last, prev := bars[len(bars)-1], bars[len(bars)-2]
+		rows = append(rows, map[string]interface{}{
+			"代码":  st.Code,
+			"名称":  st.Name,
+			"最新价": last.close,
+			"昨收":  prev.close,
+			"今开":  last.open,
+			"最高":  last.high,
+			"最低":  last.low,
+			"成交量": last.volume,
+		})
+	}
+	writeJSON(w, rows)
+}
+
+// handleDaily 只模拟 adjust=hfq-factor：模拟行情没有除权，因子恒为 1
+func (s *AKToolsStub) handleDaily(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("adjust") != "hfq-factor" {
+		http.Error(w, `{"error":"only adjust=hfq-factor is supported by the stub"}`, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, []map[string]interface{}{
+		{"date": "1900-01-01T00:00:00.000", "hfq_factor": 1.0},
+	})
+}
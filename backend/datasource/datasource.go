@@ -0,0 +1,149 @@
+package datasource
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"go-stock-analyzer/backend/storage"
+)
+
+// Quote 实时行情快照
+type Quote struct {
+	Code      string  `json:"code"`
+	Name      string  `json:"name"`
+	Price     float64 `json:"price"`
+	PrevClose float64 `json:"prev_close"`
+	Open      float64 `json:"open"`
+	High      float64 `json:"high"`
+	Low       float64 `json:"low"`
+	Volume    int64   `json:"volume"`
+	Time      string  `json:"time"`
+}
+
+// DataSource 行情数据源抽象，新增数据提供方只需实现该接口并注册
+type DataSource interface {
+	// Name 数据源名称（与 config.yaml 中 data_source 对应）
+	Name() string
+	// FetchStockList 拉取证券列表（board 由调用方按代码规则分类）
+	FetchStockList() ([]storage.StockInfo, error)
+	// FetchDailyKLine 拉取最近 days 个交易日的日 K 线（仅 OHLCV，不含指标）
+	FetchDailyKLine(symbol string, days int) ([]storage.KLine, error)
+	// FetchMinuteKLine 拉取分钟 K 线，scale 为分钟数（1/5/15/30/60，支持范围见 MinuteScaler），Date 字段为 "2006-01-02 15:04:05"
+	FetchMinuteKLine(symbol string, scale, datalen int) ([]storage.KLine, error)
+	// FetchQuotes 批量拉取实时行情
+	FetchQuotes(symbols []string) ([]Quote, error)
+}
+
+// MinuteScaler 只支持部分分钟周期的数据源实现该接口，返回支持的周期；未实现时视为支持全部周期
+type MinuteScaler interface {
+	MinuteScales() []int
+}
+
+// DefaultSource 未配置时使用的数据源
+const DefaultSource = "sina"
+
+var (
+	mu      sync.RWMutex
+	sources = map[string]func() DataSource{}
+	current DataSource
+	history DataSource
+)
+
+// Register 注册数据源构造函数，通常在各实现的 init 中调用
+func Register(name string, factory func() DataSource) {
+	mu.Lock()
+	defer mu.Unlock()
+	sources[name] = factory
+}
+
+// Names 返回已注册的数据源名称
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	out := make([]string, 0, len(sources))
+	for name := range sources {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// New 按名称创建数据源实例
+func New(name string) (DataSource, error) {
+	if name == "" {
+		name = DefaultSource
+	}
+	mu.RLock()
+	factory, ok := sources[name]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown data source: %s", name)
+	}
+	return factory(), nil
+}
+
+// Use 切换当前数据源
+func Use(name string) error {
+	ds, err := New(name)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	current = ds
+	mu.Unlock()
+	return nil
+}
+
+// Current 返回当前数据源，未调用 Use 时返回默认数据源
+func Current() DataSource {
+	mu.RLock()
+	ds := current
+	mu.RUnlock()
+	if ds != nil {
+		return ds
+	}
+	ds, _ = New(DefaultSource)
+	mu.Lock()
+	if current == nil {
+		current = ds
+	}
+	ds = current
+	mu.Unlock()
+	return ds
+}
+
+// UseHistory 指定历史日线回补使用的数据源，name 为空时沿用当前数据源
+func UseHistory(name string) error {
+	if name == "" {
+		mu.Lock()
+		history = nil
+		mu.Unlock()
+		return nil
+	}
+	ds, err := New(name)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	history = ds
+	mu.Unlock()
+	return nil
+}
+
+// History 返回历史日线数据源（用于 K 线抓取与回补），未单独配置时等同 Current
+func History() DataSource {
+	mu.RLock()
+	ds := history
+	mu.RUnlock()
+	if ds != nil {
+		return ds
+	}
+	return Current()
+}
+
+// AdjFactorSource 可提供后复权因子的数据源（可选能力，通过类型断言使用）
+type AdjFactorSource interface {
+	// FetchAdjFactors 返回按日期升序的后复权因子，Date 表示该因子的生效日
+	FetchAdjFactors(symbol string) ([]storage.AdjFactor, error)
+}
@@ -0,0 +1,182 @@
+package datasource
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"go-stock-analyzer/backend/config"
+	"go-stock-analyzer/backend/upstream"
+)
+
+// HTTP 模式
+const (
+	HTTPModeLive   = "live"   // 直接请求上游
+	HTTPModeRecord = "record" // 请求上游并把原始响应写入归档目录
+	HTTPModeReplay = "replay" // 不联网，按请求顺序回放归档中的响应
+)
+
+// DefaultArchiveDir 未配置 http_archive_dir 时的归档目录
+const DefaultArchiveDir = "backend/httparchive"
+
+// archiveTransport 录制/回放 HTTP 响应的 RoundTripper。
+// 同一 URL 的第 n 次请求对应文件 {key}-{n}.http（key 见 archiveKey），
+// 回放时按相同顺序读取，序号超出录制范围时重复返回最后一次响应，
+// 这样轮询类请求（实时行情）也能完整重放一个交易日。
+type archiveTransport struct {
+	mode string
+	dir  string
+	base http.RoundTripper
+
+	mu      sync.Mutex
+	seq     map[string]int
+	indexed map[string]bool
+}
+
+var (
+	archiveOnce sync.Once
+	archive     *archiveTransport
+)
+
+// httpTransport 按 config 中的 http_mode 返回共享的 RoundTripper，live 模式返回 nil（使用默认 Transport）
+func httpTransport() http.RoundTripper {
+	archiveOnce.Do(func() {
+		mode := config.Cfg.HTTPMode
+		if mode == "" || mode == HTTPModeLive {
+			return
+		}
+		dir := config.Cfg.HTTPArchiveDir
+		if dir == "" {
+			dir = DefaultArchiveDir
+		}
+		archive = newArchiveTransport(mode, dir, http.DefaultTransport)
+	})
+	if archive == nil {
+		return nil
+	}
+	return archive
+}
+
+// upstreamClient 返回数据源共用的上游客户端（限速/重试/熔断），录制/回放模式下挂上归档 Transport
+func upstreamClient() *upstream.Client {
+	upstream.Init(httpTransport())
+	return upstream.Default()
+}
+
+func newArchiveTransport(mode, dir string, base http.RoundTripper) *archiveTransport {
+	return &archiveTransport{mode: mode, dir: dir, base: base, seq: map[string]int{}, indexed: map[string]bool{}}
+}
+
+// volatileParams 由当前时间推算的查询参数（AKTools 历史行情的日期区间），不参与归档 key，
+// 否则某天录制的归档在之后的日期回放时永远匹配不上
+var volatileParams = []string{"start_date", "end_date"}
+
+// archiveKey method + URL 的 sha1；URL 含 volatileParams 时先去掉这些参数（其余参数按名称排序）
+func archiveKey(req *http.Request) string {
+	u := *req.URL
+	q := u.Query()
+	stripped := false
+	for _, p := range volatileParams {
+		if q.Has(p) {
+			q.Del(p)
+			stripped = true
+		}
+	}
+	if stripped {
+		u.RawQuery = q.Encode()
+	}
+	sum := sha1.Sum([]byte(req.Method + " " + u.String()))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+func (t *archiveTransport) next(key string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	n := t.seq[key]
+	t.seq[key] = n + 1
+	return n
+}
+
+func (t *archiveTransport) path(key string, n int) string {
+	return filepath.Join(t.dir, fmt.Sprintf("%s-%05d.http", key, n))
+}
+
+func (t *archiveTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := archiveKey(req)
+	n := t.next(key)
+	if t.mode == HTTPModeReplay {
+		return t.replay(req, key, n)
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if t.mode == HTTPModeRecord {
+		if err := t.record(req, resp, key, n); err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// record 把完整响应（状态行+头+body）写入归档，并恢复 resp.Body 供调用方读取
+func (t *archiveTransport) record(req *http.Request, resp *http.Response, key string, n int) error {
+	dump, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(t.dir, 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(t.path(key, n), dump, 0o644); err != nil {
+		return err
+	}
+	t.mu.Lock()
+	first := !t.indexed[key]
+	t.indexed[key] = true
+	t.mu.Unlock()
+	if first {
+		// 索引文件方便人工查找某个 URL 对应的归档
+		f, err := os.OpenFile(filepath.Join(t.dir, "index.txt"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err == nil {
+			fmt.Fprintf(f, "%s %s %s\n", key, req.Method, req.URL.String())
+			f.Close()
+		}
+	}
+	return nil
+}
+
+func (t *archiveTransport) replay(req *http.Request, key string, n int) (*http.Response, error) {
+	var data []byte
+	var err error
+	for i := n; i >= 0; i-- {
+		data, err = os.ReadFile(t.path(key, i))
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("replay: no recorded response for %s %s", req.Method, req.URL.String())
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), req)
+	if err != nil {
+		return nil, err
+	}
+	// 读出 body 避免底层 reader 的生命周期问题
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
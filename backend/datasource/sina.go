@@ -0,0 +1,316 @@
+package datasource
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-stock-analyzer/backend/calendar"
+	"go-stock-analyzer/backend/clock"
+	"go-stock-analyzer/backend/storage"
+	"go-stock-analyzer/backend/upstream"
+)
+
+func init() {
+	Register("sina", func() DataSource { return NewSinaSource() })
+}
+
+// SinaSource 新浪财经数据源
+type SinaSource struct {
+	client *upstream.Client
+}
+
+func NewSinaSource() *SinaSource {
+	return &SinaSource{client: upstreamClient()}
+}
+
+func (s *SinaSource) Name() string { return "sina" }
+
+// 新浪财经返回的股票条目（volume 有时是数字有时是字符串）
+type sinaItem struct {
+	Symbol string          `json:"symbol"`
+	Code   string          `json:"code"`
+	Name   string          `json:"name"`
+	Trade  string          `json:"trade"`
+	Volume json.RawMessage `json:"volume"`
+}
+
+// sinaNodes 证券列表抓取的行情节点：沪深 A 股、北交所、ETF、沪深指数
+var sinaNodes = []string{"hs_a", "hs_bjs", "etf_hq_fund", "hs_s"}
+
+// 新浪 K 线条目（日线与分钟线结构相同）
+type sinaKLine struct {
+	Day    string `json:"day"`
+	Open   string `json:"open"`
+	High   string `json:"high"`
+	Low    string `json:"low"`
+	Close  string `json:"close"`
+	Volume string `json:"volume"`
+}
+
+func (s *SinaSource) get(url string, header map[string]string) (string, error) {
+	body, err := s.client.Get(url, header)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// FetchStockList 分页抓取各节点证券列表；交易日开盘后成交量为 0 的视为停牌，其余时间沿用库中停牌状态。
+// 新浪列表不含上市日期，ListDate 为空
+func (s *SinaSource) FetchStockList() ([]storage.StockInfo, error) {
+	var all []storage.StockInfo
+	for _, node := range sinaNodes {
+		list, err := s.fetchNode(node)
+		if err != nil {
+			// network error - return so caller can decide
+			return nil, err
+		}
+		all = append(all, list...)
+	}
+	return all, nil
+}
+
+// suspensionKnown 交易日开盘（9:30）之后成交量才能说明是否停牌；盘前、非交易日全市场成交量均为 0
+func suspensionKnown(t time.Time) bool {
+	if !calendar.IsTradingDay(t) {
+		return false
+	}
+	switch calendar.PhaseAt(t) {
+	case calendar.PhaseMorning, calendar.PhaseLunch, calendar.PhaseAfternoon, calendar.PhaseCloseAuction:
+		return true
+	case calendar.PhaseClosed:
+		return t.Hour() >= 15
+	}
+	return false
+}
+
+func (s *SinaSource) fetchNode(node string) ([]storage.StockInfo, error) {
+	var all []storage.StockInfo
+	known := suspensionKnown(clock.Now())
+	page := 1
+	pageSize := 200
+	for {
+		url := fmt.Sprintf("http://vip.stock.finance.sina.com.cn/quotes_service/api/json_v2.php/Market_Center.getHQNodeData?page=%d&num=%d&sort=symbol&asc=1&node=%s", page, pageSize, node)
+		text, err := s.get(url, nil)
+		if err != nil {
+			return nil, err
+		}
+		text = strings.ReplaceAll(text, "'", "\"")
+		if text == "[]" || text == "" {
+			break
+		}
+		var items []sinaItem
+		if err := json.Unmarshal([]byte(text), &items); err != nil {
+			// if unmarshal fails, stop fetching
+			break
+		}
+		if len(items) == 0 {
+			break
+		}
+		for _, it := range items {
+			if len(it.Symbol) < 2 {
+				continue
+			}
+			trade := 0.0
+			// parse trade float safely
+			fmt.Sscanf(it.Trade, "%f", &trade)
+			all = append(all, storage.StockInfo{
+				Symbol:        it.Symbol,
+				Code:          it.Code,
+				Name:          it.Name,
+				Market:        strings.ToUpper(it.Symbol[:2]),
+				Trade:         trade,
+				Suspended:     known && parseFloat(strings.Trim(string(it.Volume), "\"")) == 0,
+				KeepSuspended: !known,
+			})
+		}
+		// next page（限速由 upstream 客户端统一控制）
+		page++
+		// safety cap
+		if page > 50 {
+			break
+		}
+	}
+	return all, nil
+}
+
+func (s *SinaSource) fetchKLineData(symbol string, scale, datalen int) ([]storage.KLine, error) {
+	url := fmt.Sprintf("http://money.finance.sina.com.cn/quotes_service/api/json_v2.php/CN_MarketData.getKLineData?symbol=%s&scale=%d&ma=no&datalen=%d", symbol, scale, datalen)
+	text, err := s.get(url, nil)
+	if err != nil {
+		return nil, err
+	}
+	text = strings.ReplaceAll(text, "'", "\"")
+	if text == "" || text == "null" || !strings.HasPrefix(text, "[") {
+		return nil, fmt.Errorf("empty kline for %s", symbol)
+	}
+	var raw []sinaKLine
+	if err := json.Unmarshal([]byte(text), &raw); err != nil {
+		return nil, err
+	}
+	klines := make([]storage.KLine, 0, len(raw))
+	for _, r := range raw {
+		k, err := r.toKLine(symbol)
+		if err != nil {
+			// 解析失败的行直接丢弃，避免以 0 值写入 kline 表
+			log.Printf("sina: skip bad kline row %s %s: %v", symbol, r.Day, err)
+			continue
+		}
+		klines = append(klines, k)
+	}
+	return klines, nil
+}
+
+// toKLine 严格解析一行 K 线，任一字段无法解析时返回错误
+func (r sinaKLine) toKLine(symbol string) (storage.KLine, error) {
+	k := storage.KLine{Code: symbol, Date: strings.TrimSpace(r.Day)}
+	if k.Date == "" {
+		return k, fmt.Errorf("empty date")
+	}
+	fields := []struct {
+		name string
+		raw  string
+		dst  *float64
+	}{
+		{"open", r.Open, &k.Open},
+		{"high", r.High, &k.High},
+		{"low", r.Low, &k.Low},
+		{"close", r.Close, &k.Close},
+		{"volume", r.Volume, &k.Volume},
+	}
+	for _, f := range fields {
+		v, err := strconv.ParseFloat(strings.TrimSpace(f.raw), 64)
+		if err != nil {
+			return k, fmt.Errorf("invalid %s %q", f.name, f.raw)
+		}
+		*f.dst = v
+	}
+	return k, nil
+}
+
+// FetchDailyKLine 获取日 K 线（scale=240）
+func (s *SinaSource) FetchDailyKLine(symbol string, days int) ([]storage.KLine, error) {
+	return s.fetchKLineData(symbol, 240, days)
+}
+
+// MinuteScales 新浪只提供 5/15/30/60 分钟 K 线
+func (s *SinaSource) MinuteScales() []int { return []int{5, 15, 30, 60} }
+
+// FetchMinuteKLine 获取分钟 K 线（新浪只提供 5/15/30/60 分钟）
+func (s *SinaSource) FetchMinuteKLine(symbol string, scale, datalen int) ([]storage.KLine, error) {
+	if scale < 5 {
+		return nil, fmt.Errorf("sina: %d-minute kline not supported", scale)
+	}
+	return s.fetchKLineData(symbol, scale, datalen)
+}
+
+// FetchQuotes 通过 hq.sinajs.cn 批量获取实时行情
+func (s *SinaSource) FetchQuotes(codes []string) ([]Quote, error) {
+	url := "http://hq.sinajs.cn/list=" + strings.Join(codes, ",")
+	text, err := s.get(url, map[string]string{"Referer": "http://finance.sina.com.cn/"})
+	if err != nil {
+		return nil, err
+	}
+	return parseSinaQuotes(text), nil
+}
+
+// 解析 var hq_str_sz000001="平安银行,10.00,...";
+func parseSinaQuotes(text string) []Quote {
+	var quotes []Quote
+	lines := strings.Split(text, ";")
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		eq := strings.Index(line, "=")
+		if eq < 0 {
+			continue
+		}
+		left := line[:eq]
+		start := strings.LastIndex(left, "hq_str_")
+		if start < 0 {
+			continue
+		}
+		code := left[start+7:]
+		qstart := strings.Index(line, "\"")
+		qend := strings.LastIndex(line, "\"")
+		if qstart < 0 || qend <= qstart {
+			continue
+		}
+		body := line[qstart+1 : qend]
+		fields := strings.Split(body, ",")
+		if len(fields) < 6 {
+			continue
+		}
+		var vol int64 = 0
+		if len(fields) > 8 {
+			vol = parseInt64(fields[8])
+		}
+		tstr := ""
+		if len(fields) >= 32 {
+			tstr = fields[30] + " " + fields[31]
+		}
+		quotes = append(quotes, Quote{
+			Code:      code,
+			Name:      fields[0],
+			Open:      parseFloat(fields[1]),
+			PrevClose: parseFloat(fields[2]),
+			Price:     parseFloat(fields[3]),
+			High:      parseFloat(fields[4]),
+			Low:       parseFloat(fields[5]),
+			Volume:    vol,
+			Time:      tstr,
+		})
+	}
+	return quotes
+}
+
+func parseFloat(s string) float64 {
+	v, _ := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	return v
+}
+
+func parseInt64(s string) int64 {
+	v, _ := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	return v
+}
+
+// FetchAdjFactors 解析 finance.sina.com.cn/realstock/company/{symbol}/hfq.js 的后复权因子
+// 返回内容形如 var sh600000hfq={"total":2,"data":[{"d":"2024-07-18","f":"12.3"},...]}
+func (s *SinaSource) FetchAdjFactors(symbol string) ([]storage.AdjFactor, error) {
+	url := fmt.Sprintf("http://finance.sina.com.cn/realstock/company/%s/hfq.js", symbol)
+	text, err := s.get(url, map[string]string{"Referer": "http://finance.sina.com.cn/"})
+	if err != nil {
+		return nil, err
+	}
+	start := strings.Index(text, "{")
+	if start < 0 {
+		return nil, fmt.Errorf("empty adj factors for %s", symbol)
+	}
+	var raw struct {
+		Data []struct {
+			D string `json:"d"`
+			F string `json:"f"`
+		} `json:"data"`
+	}
+	// 对象后面可能跟着注释，用 Decoder 只解析第一个 JSON 值
+	if err := json.NewDecoder(strings.NewReader(text[start:])).Decode(&raw); err != nil {
+		return nil, err
+	}
+	out := make([]storage.AdjFactor, 0, len(raw.Data))
+	for _, r := range raw.Data {
+		f := parseFloat(r.F)
+		if r.D == "" || f <= 0 {
+			continue
+		}
+		out = append(out, storage.AdjFactor{Code: symbol, Date: r.D, Factor: f})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Date < out[j].Date })
+	return out, nil
+}
@@ -0,0 +1,78 @@
+package datasource
+
+import "strings"
+
+// NormalizeSymbol 把各种代码写法统一为项目使用的 symbol 格式（如 sz000001）。
+// 支持 "000001"、"sz000001"、"SZ000001"、"000001.SZ" 等写法；无法识别时返回空串。
+func NormalizeSymbol(code string) string {
+	c := strings.ToLower(strings.TrimSpace(code))
+	if c == "" {
+		return ""
+	}
+	// 000001.sz / 600000.sh
+	if i := strings.Index(c, "."); i > 0 {
+		prefix := c[i+1:]
+		c = c[:i]
+		if isMarket(prefix) && isDigits(c) {
+			return prefix + c
+		}
+		return ""
+	}
+	// sz000001
+	if len(c) > 2 && isMarket(c[:2]) {
+		if isDigits(c[2:]) {
+			return c
+		}
+		return ""
+	}
+	if len(c) != 6 || !isDigits(c) {
+		return ""
+	}
+	return MarketOf(c) + c
+}
+
+// MarketOf 根据 6 位纯数字代码推断市场前缀（sh/sz/bj）
+func MarketOf(code string) string {
+	switch {
+	case strings.HasPrefix(code, "92"), strings.HasPrefix(code, "4"), strings.HasPrefix(code, "8"):
+		return "bj"
+	case strings.HasPrefix(code, "5"), strings.HasPrefix(code, "6"), strings.HasPrefix(code, "9"):
+		return "sh"
+	default:
+		return "sz"
+	}
+}
+
+// BareCode 去掉市场前缀，返回 6 位纯数字代码
+func BareCode(symbol string) string {
+	s := strings.ToLower(strings.TrimSpace(symbol))
+	if len(s) > 2 && isMarket(s[:2]) {
+		return s[2:]
+	}
+	if i := strings.Index(s, "."); i > 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// IsIndexSymbol 判断是否为指数代码（上证 sh000xxx、深证 sz399xxx）
+func IsIndexSymbol(symbol string) bool {
+	s := strings.ToLower(strings.TrimSpace(symbol))
+	return strings.HasPrefix(s, "sh000") || strings.HasPrefix(s, "sz399")
+}
+
+func isMarket(p string) bool {
+	return p == "sh" || p == "sz" || p == "bj"
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+package datasource
+
+import (
+	"fmt"
+
+	"go-stock-analyzer/backend/config"
+	"go-stock-analyzer/backend/storage"
+	"go-stock-analyzer/backend/tdx"
+)
+
+func init() {
+	Register("tdx", func() DataSource { return NewTDXSource(config.Cfg.TDXDir) })
+}
+
+// TDXSource 读取本地通达信 vipdoc/{sh,sz,bj}/lday/*.day 的离线数据源，只提供日线，
+// 一般配置为 history_source 用于回补与回测，实时行情仍使用在线数据源
+type TDXSource struct {
+	dir string
+}
+
+func NewTDXSource(dir string) *TDXSource {
+	return &TDXSource{dir: dir}
+}
+
+func (s *TDXSource) Name() string { return "tdx" }
+
+// FetchStockList 以本地日线文件列出证券；名称取自库中已有的证券主表，没有时使用代码
+func (s *TDXSource) FetchStockList() ([]storage.StockInfo, error) {
+	if s.dir == "" {
+		return nil, fmt.Errorf("tdx: tdx_dir not configured")
+	}
+	symbols, err := tdx.ListSymbols(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]storage.StockInfo, 0, len(symbols))
+	for _, sym := range symbols {
+		info := storage.StockInfo{Symbol: sym, Code: BareCode(sym), Name: BareCode(sym), Market: sym[:2]}
+		if st, err := storage.GetStock(sym); err == nil && st != nil {
+			info.Name = st.Name
+			info.ListDate = st.ListDate
+		}
+		out = append(out, info)
+	}
+	return out, nil
+}
+
+// FetchDailyKLine 读取本地日线文件，只保留最近 days 条
+func (s *TDXSource) FetchDailyKLine(symbol string, days int) ([]storage.KLine, error) {
+	if s.dir == "" {
+		return nil, fmt.Errorf("tdx: tdx_dir not configured")
+	}
+	sym := NormalizeSymbol(symbol)
+	if sym == "" {
+		return nil, fmt.Errorf("invalid symbol: %s", symbol)
+	}
+	klines, err := tdx.ReadDayFile(s.dir, sym)
+	if err != nil {
+		return nil, err
+	}
+	if len(klines) == 0 {
+		return nil, fmt.Errorf("empty kline for %s", sym)
+	}
+	if days > 0 && len(klines) > days {
+		klines = klines[len(klines)-days:]
+	}
+	return klines, nil
+}
+
+// MinuteScales 不支持分钟 K 线
+func (s *TDXSource) MinuteScales() []int { return nil }
+
+func (s *TDXSource) FetchMinuteKLine(symbol string, scale, datalen int) ([]storage.KLine, error) {
+	return nil, fmt.Errorf("tdx: minute kline not supported")
+}
+
+func (s *TDXSource) FetchQuotes(symbols []string) ([]Quote, error) {
+	return nil, fmt.Errorf("tdx: realtime quotes not supported")
+}
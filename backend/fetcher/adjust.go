@@ -0,0 +1,67 @@
+package fetcher
+
+import (
+	"fmt"
+
+	"go-stock-analyzer/backend/datasource"
+	"go-stock-analyzer/backend/storage"
+)
+
+// ValidAdjust 校验复权参数（""/qfq/hfq）
+func ValidAdjust(mode string) bool {
+	return mode == storage.AdjustNone || mode == storage.AdjustQFQ || mode == storage.AdjustHFQ
+}
+
+// UpdateAdjFactors 从数据源抓取复权因子并保存；数据源不支持复权因子或为指数时直接返回
+func UpdateAdjFactors(symbol string) ([]storage.AdjFactor, error) {
+	if datasource.IsIndexSymbol(symbol) {
+		return nil, nil
+	}
+	src, ok := datasource.History().(datasource.AdjFactorSource)
+	if !ok {
+		return nil, nil
+	}
+	factors, err := src.FetchAdjFactors(symbol)
+	if err != nil {
+		return nil, err
+	}
+	if len(factors) == 0 {
+		return factors, nil
+	}
+	if err := storage.SaveAdjFactors(symbol, factors); err != nil {
+		return nil, err
+	}
+	return factors, nil
+}
+
+// AdjustKLines 对不复权 K 线做复权并在复权后的序列上重算指标。
+// 优先使用库中的复权因子，库中没有时尝试从数据源抓取。
+func AdjustKLines(symbol string, klines []storage.KLine, mode string) ([]storage.KLine, error) {
+	if !ValidAdjust(mode) {
+		return nil, fmt.Errorf("invalid adjust: %s", mode)
+	}
+	if mode == storage.AdjustNone {
+		return klines, nil
+	}
+	factors, err := storage.LoadAdjFactors(symbol)
+	if err != nil {
+		return nil, err
+	}
+	if len(factors) == 0 {
+		if factors, err = UpdateAdjFactors(symbol); err != nil {
+			return nil, err
+		}
+	}
+	out := storage.AdjustKLines(klines, factors, mode)
+	ComputeIndicators(out)
+	return out, nil
+}
+
+// LoadKLinesAdjusted 从库中加载 K 线并按 mode 返回不复权、前复权或后复权序列
+func LoadKLinesAdjusted(symbol string, days int, mode string) ([]storage.KLine, error) {
+	klines, err := storage.LoadKLines(symbol, days)
+	if err != nil {
+		return nil, err
+	}
+	return AdjustKLines(symbol, klines, mode)
+}
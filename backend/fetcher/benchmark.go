@@ -0,0 +1,202 @@
+package fetcher
+
+import (
+	"fmt"
+	"log"
+	"math"
+
+	"go-stock-analyzer/backend/config"
+	"go-stock-analyzer/backend/datasource"
+	"go-stock-analyzer/backend/storage"
+)
+
+// DefaultBenchmarks 未配置 benchmark_indexes 时同步的基准指数
+var DefaultBenchmarks = []string{"sh000001", "sh000300", "sz399006"}
+
+// benchmarkNames 常用指数名称
+var benchmarkNames = map[string]string{
+	"sh000001": "上证指数",
+	"sh000016": "上证50",
+	"sh000300": "沪深300",
+	"sh000905": "中证500",
+	"sh000852": "中证1000",
+	"sz399001": "深证成指",
+	"sz399006": "创业板指",
+}
+
+// BenchmarkIndex 基准指数
+type BenchmarkIndex struct {
+	Symbol  string `json:"symbol"`
+	Name    string `json:"name"`
+	Default bool   `json:"default"`
+}
+
+// BenchmarkSymbols 返回配置的基准指数列表
+func BenchmarkSymbols() []string {
+	if len(config.Cfg.BenchmarkIndexes) > 0 {
+		return config.Cfg.BenchmarkIndexes
+	}
+	return DefaultBenchmarks
+}
+
+// DefaultBenchmark 返回策略/报告默认使用的基准指数
+func DefaultBenchmark() string {
+	if config.Cfg.Benchmark != "" {
+		return config.Cfg.Benchmark
+	}
+	return BenchmarkSymbols()[0]
+}
+
+// ListBenchmarks 返回基准指数及名称
+func ListBenchmarks() []BenchmarkIndex {
+	def := DefaultBenchmark()
+	out := []BenchmarkIndex{}
+	for _, sym := range BenchmarkSymbols() {
+		out = append(out, BenchmarkIndex{Symbol: sym, Name: benchmarkNames[sym], Default: sym == def})
+	}
+	return out
+}
+
+// SyncBenchmarks 增量同步所有基准指数日线（与个股共用 kline 表及指标列）
+func SyncBenchmarks(fullDays int) {
+	for _, sym := range BenchmarkSymbols() {
+		n, err := SyncKLine(sym, fullDays)
+		if err != nil {
+			log.Printf("sync benchmark %s error: %v", sym, err)
+			continue
+		}
+		log.Printf("synced benchmark %s: %d new bars", sym, n)
+	}
+}
+
+// LoadIndexKLines 加载指数周期 K 线，库中数据不足时先同步
+func LoadIndexKLines(symbol, period string, bars int) ([]storage.KLine, error) {
+	if !datasource.IsIndexSymbol(symbol) {
+		return nil, fmt.Errorf("not an index symbol: %s", symbol)
+	}
+	if !ValidPeriod(period) {
+		return nil, fmt.Errorf("invalid period: %s", period)
+	}
+	need := DailyBarsFor(period, bars)
+	daily, err := storage.LoadKLines(symbol, need)
+	if err != nil {
+		return nil, err
+	}
+	if len(daily) < need {
+		if _, err := SyncKLine(symbol, need); err != nil {
+			return nil, err
+		}
+		if daily, err = storage.LoadKLines(symbol, need); err != nil {
+			return nil, err
+		}
+	}
+	out, err := AggregateKLines(daily, period)
+	if err != nil {
+		return nil, err
+	}
+	if len(out) > bars {
+		out = out[len(out)-bars:]
+	}
+	return out, nil
+}
+
+// AlignBenchmark 把基准 K 线按日期对齐到 klines：每个位置取日期不晚于该 K 线的最后一根基准 K 线，
+// 个股停牌或周期日期不一致时沿用前值；没有可用基准数据的位置为零值。
+func AlignBenchmark(klines, bench []storage.KLine) []storage.KLine {
+	out := make([]storage.KLine, len(klines))
+	j := -1
+	for i, k := range klines {
+		for j+1 < len(bench) && bench[j+1].Date <= k.Date {
+			j++
+		}
+		if j >= 0 {
+			out[i] = bench[j]
+		}
+	}
+	return out
+}
+
+// RelativePoint 以区间首日为 1 归一化的净值
+type RelativePoint struct {
+	Date  string  `json:"date"`
+	Stock float64 `json:"stock"`
+	Bench float64 `json:"bench"`
+}
+
+// BenchmarkReport 个股相对基准的表现
+type BenchmarkReport struct {
+	Symbol      string          `json:"symbol"`
+	Benchmark   string          `json:"benchmark"`
+	Start       string          `json:"start"`
+	End         string          `json:"end"`
+	Return      float64         `json:"return"`       // 区间收益（%）
+	BenchReturn float64         `json:"bench_return"` // 基准区间收益（%）
+	Excess      float64         `json:"excess"`       // 超额收益（%）
+	Beta        float64         `json:"beta"`
+	Correlation float64         `json:"correlation"`
+	Series      []RelativePoint `json:"series"`
+}
+
+// CompareBenchmark 计算个股相对基准的区间收益、超额收益、Beta 与相关系数
+func CompareBenchmark(symbol string, klines []storage.KLine, benchSymbol string, bench []storage.KLine) (*BenchmarkReport, error) {
+	aligned := AlignBenchmark(klines, bench)
+	// 跳过基准尚无数据的开头部分
+	start := 0
+	for start < len(klines) && (aligned[start].Close <= 0 || klines[start].Close <= 0) {
+		start++
+	}
+	if len(klines)-start < 2 {
+		return nil, fmt.Errorf("not enough overlapping data for %s and %s", symbol, benchSymbol)
+	}
+	klines, aligned = klines[start:], aligned[start:]
+	base, benchBase := klines[0].Close, aligned[0].Close
+	r := &BenchmarkReport{
+		Symbol:    symbol,
+		Benchmark: benchSymbol,
+		Start:     klines[0].Date,
+		End:       klines[len(klines)-1].Date,
+		Series:    make([]RelativePoint, 0, len(klines)),
+	}
+	var rs, rb []float64
+	for i, k := range klines {
+		r.Series = append(r.Series, RelativePoint{Date: k.Date, Stock: k.Close / base, Bench: aligned[i].Close / benchBase})
+		if i > 0 {
+			rs = append(rs, k.Close/klines[i-1].Close-1)
+			rb = append(rb, aligned[i].Close/aligned[i-1].Close-1)
+		}
+	}
+	last := r.Series[len(r.Series)-1]
+	r.Return = (last.Stock - 1) * 100
+	r.BenchReturn = (last.Bench - 1) * 100
+	r.Excess = r.Return - r.BenchReturn
+	r.Beta, r.Correlation = betaCorr(rs, rb)
+	return r, nil
+}
+
+// betaCorr 日收益率序列的 Beta 与相关系数
+func betaCorr(rs, rb []float64) (beta, corr float64) {
+	n := float64(len(rs))
+	if n < 2 {
+		return 0, 0
+	}
+	var ms, mb float64
+	for i := range rs {
+		ms += rs[i]
+		mb += rb[i]
+	}
+	ms /= n
+	mb /= n
+	var cov, vs, vb float64
+	for i := range rs {
+		cov += (rs[i] - ms) * (rb[i] - mb)
+		vs += (rs[i] - ms) * (rs[i] - ms)
+		vb += (rb[i] - mb) * (rb[i] - mb)
+	}
+	if vb > 0 {
+		beta = cov / vb
+	}
+	if vs > 0 && vb > 0 {
+		corr = cov / math.Sqrt(vs*vb)
+	}
+	return beta, corr
+}
@@ -0,0 +1,55 @@
+package fetcher
+
+import (
+	"fmt"
+	"sort"
+
+	"go-stock-analyzer/backend/datasource"
+	"go-stock-analyzer/backend/storage"
+)
+
+// ImportKLines 导入外部日 K 线（如 CSV），与库中已有数据合并（同日期以导入数据为准），
+// 在合并后的完整序列上重新计算指标并保存；replace 为 true 时在同一事务中替换该证券已有的日 K 线。
+// 返回规范化后的 symbol 与写入的导入行数（同一日期重复的导入行只算一次）。
+func ImportKLines(symbol string, klines []storage.KLine, replace bool) (string, int, error) {
+	sym := datasource.NormalizeSymbol(symbol)
+	if sym == "" {
+		return "", 0, fmt.Errorf("invalid symbol: %s", symbol)
+	}
+	if len(klines) == 0 {
+		return sym, 0, nil
+	}
+	merged := map[string]storage.KLine{}
+	if !replace {
+		stored, err := storage.LoadAllKLines(sym)
+		if err != nil {
+			return sym, 0, err
+		}
+		for _, k := range stored {
+			merged[k.Date] = k
+		}
+	}
+	imported := map[string]bool{}
+	for _, k := range klines {
+		k.Code = sym
+		merged[k.Date] = k
+		imported[k.Date] = true
+	}
+	all := make([]storage.KLine, 0, len(merged))
+	for _, k := range merged {
+		all = append(all, k)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Date < all[j].Date })
+	ComputeIndicators(all)
+	if replace {
+		n, err := storage.ReplaceKLines(sym, all)
+		if err != nil {
+			return sym, 0, err
+		}
+		return sym, n, nil
+	}
+	if err := storage.SaveKLines(sym, all); err != nil {
+		return sym, 0, err
+	}
+	return sym, len(imported), nil
+}
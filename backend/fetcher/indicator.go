@@ -1,5 +1,7 @@
 package fetcher
 
+import "go-stock-analyzer/backend/storage"
+
 func CalcMA(values []float64, n int) float64 {
 	if len(values) < n {
 		return 0
@@ -43,3 +45,74 @@ func CalcMACD(values []float64) (dif, dea, macd float64) {
 	macd = 2 * (dif - dea)
 	return
 }
+
+// maWindow IndicatorState 保留的收盘价个数（最长均线 MA30）
+const maWindow = 30
+
+// IndicatorState 增量指标计算器：逐根追加 K 线并计算 MA5/10/20/30 与 MACD，无需重算历史。
+// 均线按窗口从旧到新求和、EMA 递推公式与 CalcMA / CalcEMASequence 相同，结果与逐前缀重算逐位一致。
+type IndicatorState struct {
+	closes            [maWindow]float64 // 最近 maWindow 个收盘价的环形缓冲
+	count             int
+	ema12, ema26, dea float64
+}
+
+func NewIndicatorState() *IndicatorState {
+	return &IndicatorState{}
+}
+
+// NewIndicatorStateFrom 用已有 K 线回放出计算器状态，之后可对新 K 线调用 Next
+func NewIndicatorStateFrom(history []storage.KLine) *IndicatorState {
+	st := NewIndicatorState()
+	for _, k := range history {
+		st.push(k.Close)
+	}
+	return st
+}
+
+// Count 已处理的 K 线数
+func (s *IndicatorState) Count() int { return s.count }
+
+// Next 追加一根 K 线并就地写入其 MA/MACD 字段
+func (s *IndicatorState) Next(k *storage.KLine) {
+	s.push(k.Close)
+	k.MA5 = s.ma(5)
+	k.MA10 = s.ma(10)
+	k.MA20 = s.ma(20)
+	k.MA30 = s.ma(30)
+	k.DIF = s.ema12 - s.ema26
+	k.DEA = s.dea
+	k.MACD = 2 * (k.DIF - k.DEA)
+}
+
+// Append 依次追加多根 K 线（如每日同步得到的新 K 线）
+func (s *IndicatorState) Append(klines []storage.KLine) {
+	for i := range klines {
+		s.Next(&klines[i])
+	}
+}
+
+func (s *IndicatorState) push(c float64) {
+	s.closes[s.count%maWindow] = c
+	if s.count == 0 {
+		s.ema12, s.ema26 = c, c
+		s.dea = 0
+	} else {
+		s.ema12 = (c-s.ema12)*(2.0/13) + s.ema12
+		s.ema26 = (c-s.ema26)*(2.0/27) + s.ema26
+		s.dea = (s.ema12-s.ema26-s.dea)*(2.0/10) + s.dea
+	}
+	s.count++
+}
+
+// ma 最近 n 个收盘价的均值，不足 n 个时为 0
+func (s *IndicatorState) ma(n int) float64 {
+	if s.count < n {
+		return 0
+	}
+	sum := 0.0
+	for i := s.count - n; i < s.count; i++ {
+		sum += s.closes[i%maWindow]
+	}
+	return sum / float64(n)
+}
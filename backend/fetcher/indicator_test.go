@@ -0,0 +1,107 @@
+package fetcher
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"go-stock-analyzer/backend/storage"
+)
+
+// computeIndicatorsNaive 旧实现：对每个前缀重新计算全部指标，O(n²)，作为对照基准
+func computeIndicatorsNaive(klines []storage.KLine) {
+	closes := make([]float64, 0, len(klines))
+	for _, k := range klines {
+		closes = append(closes, k.Close)
+	}
+	for i := range klines {
+		sub := closes[:i+1]
+		klines[i].MA5 = CalcMA(sub, 5)
+		klines[i].MA10 = CalcMA(sub, 10)
+		klines[i].MA20 = CalcMA(sub, 20)
+		klines[i].MA30 = CalcMA(sub, 30)
+		dif, dea, macd := CalcMACD(sub)
+		klines[i].DIF = dif
+		klines[i].DEA = dea
+		klines[i].MACD = macd
+	}
+}
+
+func randomWalk(n int, seed int64) []storage.KLine {
+	rng := rand.New(rand.NewSource(seed))
+	out := make([]storage.KLine, n)
+	price := 10.0
+	for i := range out {
+		price = math.Max(0.5, price*(1+(rng.Float64()-0.5)*0.06))
+		out[i] = storage.KLine{Code: "sz000001", Date: fmt.Sprintf("d%06d", i), Close: math.Round(price*100) / 100}
+	}
+	return out
+}
+
+func clone(k []storage.KLine) []storage.KLine {
+	return append([]storage.KLine(nil), k...)
+}
+
+// ComputeIndicators 与增量 IndicatorState（前一半回放、后一半逐根追加）都应与逐前缀重算逐位一致
+func TestIndicatorStateMatchesNaive(t *testing.T) {
+	for _, n := range []int{1, 2, 29, 30, 31, 250, 1000} {
+		base := randomWalk(n, int64(n))
+		want := clone(base)
+		computeIndicatorsNaive(want)
+		got := clone(base)
+		ComputeIndicators(got)
+		half := n / 2
+		incr := clone(base)
+		st := NewIndicatorStateFrom(incr[:half])
+		st.Append(incr[half:])
+		if st.Count() != n {
+			t.Fatalf("bars=%d: state count %d", n, st.Count())
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("bars=%d: ComputeIndicators differs at %s: %+v vs %+v", n, want[i].Date, got[i], want[i])
+			}
+			if i >= half && incr[i] != want[i] {
+				t.Fatalf("bars=%d: IndicatorState differs at %s: %+v vs %+v", n, want[i].Date, incr[i], want[i])
+			}
+		}
+	}
+}
+
+func benchmarkSizes(b *testing.B, fn func(b *testing.B, base []storage.KLine)) {
+	for _, n := range []int{250, 1000, 5000} {
+		base := randomWalk(n, 1)
+		b.Run(fmt.Sprintf("bars=%d", n), func(b *testing.B) { fn(b, base) })
+	}
+}
+
+func BenchmarkComputeIndicatorsNaive(b *testing.B) {
+	benchmarkSizes(b, func(b *testing.B, base []storage.KLine) {
+		for i := 0; i < b.N; i++ {
+			computeIndicatorsNaive(clone(base))
+		}
+	})
+}
+
+func BenchmarkComputeIndicators(b *testing.B) {
+	benchmarkSizes(b, func(b *testing.B, base []storage.KLine) {
+		for i := 0; i < b.N; i++ {
+			ComputeIndicators(clone(base))
+		}
+	})
+}
+
+// 增量：历史已回放，只追加最后一根
+func BenchmarkIndicatorStateAppendOne(b *testing.B) {
+	benchmarkSizes(b, func(b *testing.B, base []storage.KLine) {
+		n := len(base)
+		st := NewIndicatorStateFrom(base[:n-1])
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			s := *st
+			k := base[n-1]
+			s.Next(&k)
+		}
+	})
+}
@@ -0,0 +1,71 @@
+package fetcher
+
+import (
+	"fmt"
+
+	"go-stock-analyzer/backend/datasource"
+	"go-stock-analyzer/backend/storage"
+)
+
+// MinuteScales 支持的分钟 K 线周期
+var MinuteScales = []int{1, 5, 15, 30, 60}
+
+// ValidMinuteScale 校验分钟周期
+func ValidMinuteScale(scale int) bool {
+	for _, s := range MinuteScales {
+		if s == scale {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckMinuteScale 校验分钟周期且当前数据源支持该周期
+func CheckMinuteScale(scale int) error {
+	if !ValidMinuteScale(scale) {
+		return fmt.Errorf("invalid minute scale: %d", scale)
+	}
+	ds := datasource.Current()
+	ms, ok := ds.(datasource.MinuteScaler)
+	if !ok {
+		return nil
+	}
+	for _, s := range ms.MinuteScales() {
+		if s == scale {
+			return nil
+		}
+	}
+	return fmt.Errorf("%d-minute kline not supported by data source %s (supported: %v)", scale, ds.Name(), ms.MinuteScales())
+}
+
+// SupportedMinuteScales 从 scales 中筛出当前数据源支持的周期，不支持的周期一并返回
+func SupportedMinuteScales(scales []int) (ok, unsupported []int) {
+	for _, s := range scales {
+		if CheckMinuteScale(s) == nil {
+			ok = append(ok, s)
+		} else {
+			unsupported = append(unsupported, s)
+		}
+	}
+	return ok, unsupported
+}
+
+// FetchMinuteKLine 通过当前数据源获取分钟 K 线
+func FetchMinuteKLine(symbol string, scale, datalen int) ([]storage.KLine, error) {
+	if err := CheckMinuteScale(scale); err != nil {
+		return nil, err
+	}
+	return datasource.Current().FetchMinuteKLine(symbol, scale, datalen)
+}
+
+// SyncMinuteKLine 抓取并保存指定周期的分钟 K 线，返回保存条数
+func SyncMinuteKLine(symbol string, scale, datalen int) (int, error) {
+	klines, err := FetchMinuteKLine(symbol, scale, datalen)
+	if err != nil {
+		return 0, err
+	}
+	if err := storage.SaveMinuteKLines(symbol, scale, klines); err != nil {
+		return 0, err
+	}
+	return len(klines), nil
+}
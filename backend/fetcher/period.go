@@ -0,0 +1,115 @@
+package fetcher
+
+import (
+	"fmt"
+	"time"
+
+	"go-stock-analyzer/backend/storage"
+)
+
+// K 线周期
+const (
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+// ValidPeriod 校验周期参数，空串视为日线
+func ValidPeriod(period string) bool {
+	return period == "" || period == PeriodDay || period == PeriodWeek || period == PeriodMonth
+}
+
+// DailyBarsFor 返回聚合出 bars 根周期 K 线大约需要的日线根数
+func DailyBarsFor(period string, bars int) int {
+	switch period {
+	case PeriodWeek:
+		return bars*5 + 5
+	case PeriodMonth:
+		return bars*23 + 23
+	default:
+		return bars
+	}
+}
+
+// periodKey 返回日期所属的周期标识：周线按 ISO 周，月线按自然月
+func periodKey(date, period string) (string, error) {
+	if len(date) > 10 {
+		date = date[:10]
+	}
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return "", err
+	}
+	if period == PeriodWeek {
+		y, w := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", y, w), nil
+	}
+	return t.Format("2006-01"), nil
+}
+
+// AggregateKLines 把按日期升序的日线聚合为周线或月线并计算指标。
+// 每根周期 K 线：开盘取首日、收盘取末日、最高/最低取极值、成交量求和，Date 为周期内最后一个交易日。
+func AggregateKLines(daily []storage.KLine, period string) ([]storage.KLine, error) {
+	if period == "" || period == PeriodDay {
+		return daily, nil
+	}
+	if !ValidPeriod(period) {
+		return nil, fmt.Errorf("invalid period: %s", period)
+	}
+	out := []storage.KLine{}
+	lastKey := ""
+	for _, d := range daily {
+		key, err := periodKey(d.Date, period)
+		if err != nil {
+			return nil, err
+		}
+		if key != lastKey || len(out) == 0 {
+			out = append(out, storage.KLine{
+				Code:   d.Code,
+				Date:   d.Date,
+				Open:   d.Open,
+				High:   d.High,
+				Low:    d.Low,
+				Close:  d.Close,
+				Volume: d.Volume,
+			})
+			lastKey = key
+			continue
+		}
+		cur := &out[len(out)-1]
+		cur.Date = d.Date
+		cur.Close = d.Close
+		cur.Volume += d.Volume
+		if d.High > cur.High {
+			cur.High = d.High
+		}
+		if d.Low < cur.Low {
+			cur.Low = d.Low
+		}
+	}
+	ComputeIndicators(out)
+	return out, nil
+}
+
+// LoadPeriodKLines 从库中加载日线，复权后聚合为指定周期，返回最近 bars 根；
+// end 非空时只使用日期不晚于 end 的日线（按历史时点运行策略）
+func LoadPeriodKLines(symbol, period string, bars int, adjust, end string) ([]storage.KLine, error) {
+	if !ValidPeriod(period) {
+		return nil, fmt.Errorf("invalid period: %s", period)
+	}
+	daily, err := storage.LoadKLinesUntil(symbol, end, DailyBarsFor(period, bars))
+	if err != nil {
+		return nil, err
+	}
+	if daily, err = AdjustKLines(symbol, daily, adjust); err != nil {
+		return nil, err
+	}
+	out, err := AggregateKLines(daily, period)
+	if err != nil {
+		return nil, err
+	}
+	if len(out) > bars {
+		out = out[len(out)-bars:]
+	}
+	return out, nil
+}
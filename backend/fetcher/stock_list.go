@@ -1,101 +1,111 @@
 package fetcher
 
 import (
-	"encoding/json"
 	"fmt"
+	"go-stock-analyzer/backend/clock"
+	"go-stock-analyzer/backend/datasource"
 	"go-stock-analyzer/backend/storage"
-	"io"
-	"net/http"
 	"strings"
-	"time"
 )
 
-// 新浪财经返回的股票条目
-type sinaItem struct {
-	Symbol string `json:"symbol"`
-	Code   string `json:"code"`
-	Name   string `json:"name"`
-	Trade  string `json:"trade"`
+// 证券分类结果
+type securityClass struct {
+	SecType  string
+	Exchange string
+	Board    string
 }
 
-// 板块分类
-func classifyBoard(symbol, code string) string {
-	// symbol like "sh600000" or "sz000001"
-	if strings.HasPrefix(symbol, "sh") {
-		if strings.HasPrefix(code, "688") {
-			return "科创板"
+// 按代码规则分类证券类型、交易所与板块；不认识的代码（如 B 股）返回空 Board
+func classifySecurity(symbol, code string) securityClass {
+	// symbol like "sh600000" / "sz000001" / "bj430047"
+	switch {
+	case strings.HasPrefix(symbol, "sh"):
+		c := securityClass{Exchange: "SSE", SecType: storage.SecTypeStock}
+		switch {
+		case strings.HasPrefix(code, "688"), strings.HasPrefix(code, "689"):
+			c.Board = "科创板"
+		case strings.HasPrefix(code, "60"):
+			c.Board = "上证主板"
+		case strings.HasPrefix(code, "000"):
+			c.SecType, c.Board = storage.SecTypeIndex, "指数"
+		case strings.HasPrefix(code, "51"), strings.HasPrefix(code, "56"), strings.HasPrefix(code, "58"):
+			c.SecType, c.Board = storage.SecTypeETF, "ETF"
+		case strings.HasPrefix(code, "50"):
+			c.SecType, c.Board = storage.SecTypeFund, "基金"
 		}
-		// treat other sh as 上证主板 if starts with 6
-		if strings.HasPrefix(code, "6") {
-			return "上证主板"
+		return c
+	case strings.HasPrefix(symbol, "sz"):
+		c := securityClass{Exchange: "SZSE", SecType: storage.SecTypeStock}
+		switch {
+		case strings.HasPrefix(code, "300"), strings.HasPrefix(code, "301"), strings.HasPrefix(code, "302"):
+			c.Board = "创业板"
+		case strings.HasPrefix(code, "000"), strings.HasPrefix(code, "001"):
+			c.Board = "深证主板"
+		case strings.HasPrefix(code, "002"), strings.HasPrefix(code, "003"), strings.HasPrefix(code, "004"):
+			// 中小板 2021 年已并入主板，这里单独标记便于筛选
+			c.Board = "中小板"
+		case strings.HasPrefix(code, "399"):
+			c.SecType, c.Board = storage.SecTypeIndex, "指数"
+		case strings.HasPrefix(code, "159"):
+			c.SecType, c.Board = storage.SecTypeETF, "ETF"
+		case strings.HasPrefix(code, "16"):
+			c.SecType, c.Board = storage.SecTypeFund, "基金"
 		}
+		return c
+	case strings.HasPrefix(symbol, "bj"):
+		return securityClass{Exchange: "BSE", SecType: storage.SecTypeStock, Board: "北交所"}
 	}
-	if strings.HasPrefix(symbol, "sz") {
-		if strings.HasPrefix(code, "300") || strings.HasPrefix(code, "301") {
-			return "创业板"
-		}
-		if strings.HasPrefix(code, "000") {
-			return "深证主板"
+	return securityClass{}
+}
+
+// 板块分类
+func classifyBoard(symbol, code string) string {
+	return classifySecurity(symbol, code).Board
+}
+
+// isSTName 名称带 ST / *ST / S*ST / SST 前缀的为风险警示股
+func isSTName(name string) bool {
+	n := strings.ToUpper(strings.TrimSpace(name))
+	for _, p := range []string{"ST", "*ST", "S*ST", "SST"} {
+		if strings.HasPrefix(n, p) {
+			return true
 		}
 	}
-	return ""
+	return false
 }
 
-// FetchAllStocks 从新浪财经抓取沪深A股列表并分类为四大板块
+// FetchAllStocks 通过当前数据源抓取证券列表（A 股、北交所、ETF、指数）并分类
 func FetchAllStocks() ([]storage.StockInfo, error) {
-	var all []storage.StockInfo
-	page := 1
-	pageSize := 200
-
-	client := &http.Client{Timeout: 15 * time.Second}
-	for {
-		url := fmt.Sprintf("http://vip.stock.finance.sina.com.cn/quotes_service/api/json_v2.php/Market_Center.getHQNodeData?page=%d&num=%d&sort=symbol&asc=1&node=hs_a", page, pageSize)
-		resp, err := client.Get(url)
-		if err != nil {
-			// network error - return so caller can decide
-			return nil, err
-		}
-		body, _ := io.ReadAll(resp.Body)
-		resp.Body.Close()
-		text := strings.TrimSpace(string(body))
-		text = strings.ReplaceAll(text, "'", "\"")
-		if text == "[]" || text == "" {
-			break
-		}
-		var items []sinaItem
-		if err := json.Unmarshal([]byte(text), &items); err != nil {
-			// if unmarshal fails, stop fetching
-			break
-		}
-		if len(items) == 0 {
-			break
-		}
-		for _, it := range items {
-			board := classifyBoard(it.Symbol, it.Code)
-			if board == "" {
-				continue
-			}
-			trade := 0.0
-			// parse trade float safely
-			fmt.Sscanf(it.Trade, "%f", &trade)
-			s := storage.StockInfo{
-				Symbol: it.Symbol,
-				Code:   it.Code,
-				Name:   it.Name,
-				Market: strings.ToUpper(it.Symbol[:2]),
-				Board:  board,
-				Trade:  trade,
-			}
-			all = append(all, s)
+	list, err := datasource.Current().FetchStockList()
+	if err != nil {
+		return nil, err
+	}
+	all := make([]storage.StockInfo, 0, len(list))
+	seen := make(map[string]bool, len(list))
+	for _, s := range list {
+		if seen[s.Symbol] {
+			continue
 		}
-		// next page
-		page++
-		// sleep a bit to be polite
-		time.Sleep(200 * time.Millisecond)
-		// safety cap
-		if page > 50 {
-			break
+		c := classifySecurity(s.Symbol, s.Code)
+		if c.Board == "" {
+			continue
 		}
+		seen[s.Symbol] = true
+		s.SecType, s.Exchange, s.Board = c.SecType, c.Exchange, c.Board
+		s.IsST = c.SecType == storage.SecTypeStock && isSTName(s.Name)
+		all = append(all, s)
 	}
 	return all, nil
 }
+
+// RefreshStockList 抓取最新证券列表并与库中对比，记录上市/退市/更名/板块变动
+func RefreshStockList() ([]storage.StockChange, error) {
+	list, err := FetchAllStocks()
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("fetched stock list empty")
+	}
+	return storage.SyncStocks(list, clock.Today())
+}
@@ -0,0 +1,91 @@
+package fetcher
+
+import (
+	"go-stock-analyzer/backend/calendar"
+	"go-stock-analyzer/backend/clock"
+	"go-stock-analyzer/backend/datasource"
+	"go-stock-analyzer/backend/storage"
+)
+
+// tradingDaysSince 按交易日历计算 last（不含）到今天（含）之间的交易日数
+func tradingDaysSince(last string) int {
+	t, err := clock.ParseDate(last)
+	if err != nil {
+		return -1
+	}
+	return calendar.CountTradingDays(t, clock.Date(clock.Now()))
+}
+
+// SyncKLine 增量同步日 K 线：库中没有数据时全量抓取 fullDays 天；
+// 否则只抓取最后一个已存日期之后缺失的部分（连同最后一天一起刷新，覆盖盘中写入的未收盘数据），
+// 并基于库中已有序列延续计算新行的指标。返回写入的行数。
+func SyncKLine(symbol string, fullDays int) (int, error) {
+	last, err := storage.LastKLineDate(symbol)
+	if err != nil {
+		return 0, err
+	}
+	gap := tradingDaysSince(last)
+	if last == "" || gap < 0 {
+		klines, err := FetchKLine(symbol, fullDays)
+		if err != nil {
+			return 0, err
+		}
+		if err := storage.SaveKLines(symbol, klines); err != nil {
+			return 0, err
+		}
+		return len(klines), nil
+	}
+
+	// 多取 2 根，保证与已存数据有重叠
+	fetched, err := datasource.History().FetchDailyKLine(symbol, gap+2)
+	if err != nil {
+		return 0, err
+	}
+	if len(fetched) > 0 && fetched[0].Date > last {
+		// 返回的数据与库中没有重叠（缺口比估算大），扩大窗口重取一次
+		if fetched, err = datasource.History().FetchDailyKLine(symbol, gap*2+30); err != nil {
+			return 0, err
+		}
+	}
+	tail := []storage.KLine{}
+	for _, k := range fetched {
+		if k.Date >= last {
+			tail = append(tail, k)
+		}
+	}
+	if len(tail) == 0 {
+		return 0, nil
+	}
+
+	stored, err := storage.LoadAllKLines(symbol)
+	if err != nil {
+		return 0, err
+	}
+	merged := make([]storage.KLine, 0, len(stored)+len(tail))
+	for _, k := range stored {
+		if k.Date < tail[0].Date {
+			merged = append(merged, k)
+		}
+	}
+	prefix := len(merged)
+	merged = append(merged, tail...)
+	// 用已存历史回放出指标状态，只计算新追加的 K 线
+	NewIndicatorStateFrom(merged[:prefix]).Append(merged[prefix:])
+	if err := storage.SaveKLines(symbol, merged[prefix:]); err != nil {
+		return 0, err
+	}
+	return len(merged) - prefix, nil
+}
+
+// SyncKLineIfStale 库中没有数据或最后日期早于最近交易日时调用 SyncKLine，否则不访问上游；
+// 供读接口在返回前补齐数据，避免每次请求都抓取并覆盖已存 K 线
+func SyncKLineIfStale(symbol string, fullDays int) (int, error) {
+	last, err := storage.LastKLineDate(symbol)
+	if err != nil {
+		return 0, err
+	}
+	if last != "" && tradingDaysSince(last) == 0 {
+		return 0, nil
+	}
+	return SyncKLine(symbol, fullDays)
+}
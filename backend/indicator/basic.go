@@ -0,0 +1,191 @@
+package indicator
+
+import (
+	"math"
+
+	"go-stock-analyzer/backend/storage"
+)
+
+// 本包的函数都返回与输入等长的序列，数据不足（预热期）的位置为 0，与 fetcher.CalcMA 的约定一致。
+// 公式按通达信习惯：EMA/SMA 以首个值为初值，STD 为样本标准差，除数为 0 时结果为 0。
+
+// Series K 线的价格/成交量序列
+type Series struct {
+	Open, High, Low, Close, Volume []float64
+}
+
+// FromKLines 从 K 线提取序列
+func FromKLines(klines []storage.KLine) Series {
+	n := len(klines)
+	s := Series{
+		Open:   make([]float64, n),
+		High:   make([]float64, n),
+		Low:    make([]float64, n),
+		Close:  make([]float64, n),
+		Volume: make([]float64, n),
+	}
+	for i, k := range klines {
+		s.Open[i], s.High[i], s.Low[i], s.Close[i], s.Volume[i] = k.Open, k.High, k.Low, k.Close, k.Volume
+	}
+	return s
+}
+
+// div 除数为 0 时返回 0（通达信约定）
+func div(a, b float64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return a / b
+}
+
+// MA 简单移动平均，前 n-1 个位置为 0
+func MA(x []float64, n int) []float64 {
+	out := make([]float64, len(x))
+	if n <= 0 {
+		return out
+	}
+	sum := 0.0
+	for i, v := range x {
+		sum += v
+		if i >= n {
+			sum -= x[i-n]
+		}
+		if i >= n-1 {
+			out[i] = sum / float64(n)
+		}
+	}
+	return out
+}
+
+// EMA 指数移动平均：Y = (2*X + (N-1)*Y') / (N+1)，首值为 X[0]
+func EMA(x []float64, n int) []float64 {
+	out := make([]float64, len(x))
+	if len(x) == 0 || n <= 0 {
+		return out
+	}
+	out[0] = x[0]
+	for i := 1; i < len(x); i++ {
+		out[i] = (2*x[i] + float64(n-1)*out[i-1]) / float64(n+1)
+	}
+	return out
+}
+
+// SMA 通达信 SMA(X,N,M)：Y = (M*X + (N-M)*Y') / N，首值为 X[0]
+func SMA(x []float64, n, m int) []float64 {
+	out := make([]float64, len(x))
+	if len(x) == 0 || n <= 0 {
+		return out
+	}
+	out[0] = x[0]
+	for i := 1; i < len(x); i++ {
+		out[i] = (float64(m)*x[i] + float64(n-m)*out[i-1]) / float64(n)
+	}
+	return out
+}
+
+// smaFrom 与 SMA 相同，但从 start 开始计算（之前为 0），用于首个值无定义的序列（如依赖前一日收盘）
+func smaFrom(x []float64, n, m, start int) []float64 {
+	out := make([]float64, len(x))
+	if start >= len(x) {
+		return out
+	}
+	copy(out[start:], SMA(x[start:], n, m))
+	return out
+}
+
+// STD 样本标准差（N-1），前 n-1 个位置为 0
+func STD(x []float64, n int) []float64 {
+	out := make([]float64, len(x))
+	if n <= 1 {
+		return out
+	}
+	sum, sq := 0.0, 0.0
+	for i, v := range x {
+		sum += v
+		sq += v * v
+		if i >= n {
+			sum -= x[i-n]
+			sq -= x[i-n] * x[i-n]
+		}
+		if i >= n-1 {
+			variance := (sq - sum*sum/float64(n)) / float64(n-1)
+			if variance > 0 {
+				out[i] = math.Sqrt(variance)
+			}
+		}
+	}
+	return out
+}
+
+// SUM 最近 n 个值之和，数据不足 n 个时对已有数据求和
+func SUM(x []float64, n int) []float64 {
+	out := make([]float64, len(x))
+	sum := 0.0
+	for i, v := range x {
+		sum += v
+		if n > 0 && i >= n {
+			sum -= x[i-n]
+		}
+		out[i] = sum
+	}
+	return out
+}
+
+// HHV 最近 n 个值的最大值，数据不足 n 个时取已有数据
+func HHV(x []float64, n int) []float64 {
+	return window(x, n, func(a, b float64) bool { return a > b })
+}
+
+// LLV 最近 n 个值的最小值，数据不足 n 个时取已有数据
+func LLV(x []float64, n int) []float64 {
+	return window(x, n, func(a, b float64) bool { return a < b })
+}
+
+// window 单调队列求滑动窗口极值，better(a, b) 为 true 表示 a 优于 b
+func window(x []float64, n int, better func(a, b float64) bool) []float64 {
+	out := make([]float64, len(x))
+	if n <= 0 || len(x) == 0 {
+		return out
+	}
+	dq := make([]int, 0, min(n, len(x)))
+	for i, v := range x {
+		for len(dq) > 0 && !better(x[dq[len(dq)-1]], v) {
+			dq = dq[:len(dq)-1]
+		}
+		dq = append(dq, i)
+		if dq[0] <= i-n {
+			dq = dq[1:]
+		}
+		out[i] = x[dq[0]]
+	}
+	return out
+}
+
+// REF 前 n 个值，不足时为 0
+func REF(x []float64, n int) []float64 {
+	out := make([]float64, len(x))
+	if n < 0 {
+		return out
+	}
+	for i := n; i < len(x); i++ {
+		out[i] = x[i-n]
+	}
+	return out
+}
+
+// AVEDEV 最近 n 个值的平均绝对偏差，前 n-1 个位置为 0
+func AVEDEV(x []float64, n int) []float64 {
+	out := make([]float64, len(x))
+	if n <= 0 {
+		return out
+	}
+	ma := MA(x, n)
+	for i := n - 1; i < len(x); i++ {
+		d := 0.0
+		for j := i - n + 1; j <= i; j++ {
+			d += math.Abs(x[j] - ma[i])
+		}
+		out[i] = d / float64(n)
+	}
+	return out
+}
@@ -0,0 +1,124 @@
+package indicator
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"strings"
+	"sync"
+
+	"go-stock-analyzer/backend/storage"
+)
+
+// Frame 一段 K 线上的指标计算结果，按规范名缓存，同一指标只计算一次
+type Frame struct {
+	s     Series
+	mu    sync.Mutex
+	cache map[string][]float64
+}
+
+func NewFrame(s Series) *Frame {
+	return &Frame{s: s, cache: map[string][]float64{}}
+}
+
+// Len K 线根数
+func (f *Frame) Len() int { return len(f.s.Close) }
+
+// Get 按名称取指标序列（名称格式见 Parse）；返回的切片与缓存共享，调用方不要修改
+func (f *Frame) Get(name string) ([]float64, error) {
+	r, err := Parse(name)
+	if err != nil {
+		return nil, err
+	}
+	return f.GetRef(r), nil
+}
+
+// GetRef 取已解析指标的序列；多输出指标一次算出全部输出并缓存
+func (f *Frame) GetRef(r Ref) []float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if v, ok := f.cache[r.Key()]; ok {
+		return v
+	}
+	outs := r.Def.Calc(f.s, r.Params)
+	for i, v := range outs {
+		f.cache[Ref{Def: r.Def, Params: r.Params, Output: i}.Key()] = v
+	}
+	return outs[r.Output]
+}
+
+// Last 取指标最后一个值
+func (f *Frame) Last(name string) (float64, error) {
+	v, err := f.Get(name)
+	if err != nil || len(v) == 0 {
+		return 0, err
+	}
+	return v[len(v)-1], nil
+}
+
+// Compute 见包级 Compute
+func (f *Frame) Compute(names ...string) (map[string][]float64, error) {
+	if len(names) == 0 {
+		names = Names()
+	}
+	out := map[string][]float64{}
+	for _, name := range names {
+		if g, ok := groups[strings.ToLower(name)]; ok {
+			for _, o := range g {
+				v, err := f.Get(o[1])
+				if err != nil {
+					return nil, err
+				}
+				out[o[0]] = v
+			}
+			continue
+		}
+		v, err := f.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		out[name] = v
+	}
+	return out, nil
+}
+
+// frameCacheSize 全局缓存的 Frame 个数上限
+const frameCacheSize = 256
+
+var frameCache = struct {
+	sync.Mutex
+	m     map[uint64]*Frame
+	order []uint64
+}{m: map[uint64]*Frame{}}
+
+// Cached 返回 klines 对应的 Frame；内容（日期与 OHLCV）相同的 K 线共享同一个 Frame，
+// 这样同一只股票被多个策略或多次请求使用时指标只计算一次
+func Cached(klines []storage.KLine) *Frame {
+	key := fingerprint(klines)
+	frameCache.Lock()
+	defer frameCache.Unlock()
+	if f, ok := frameCache.m[key]; ok {
+		return f
+	}
+	f := NewFrame(FromKLines(klines))
+	if len(frameCache.order) >= frameCacheSize {
+		delete(frameCache.m, frameCache.order[0])
+		frameCache.order = frameCache.order[1:]
+	}
+	frameCache.m[key] = f
+	frameCache.order = append(frameCache.order, key)
+	return f
+}
+
+func fingerprint(klines []storage.KLine) uint64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	for _, k := range klines {
+		h.Write([]byte(k.Date))
+		for _, v := range []float64{k.Open, k.High, k.Low, k.Close, k.Volume} {
+			binary.LittleEndian.PutUint64(buf[:], math.Float64bits(v))
+			h.Write(buf[:])
+		}
+	}
+	return h.Sum64()
+}
@@ -0,0 +1,98 @@
+package indicator
+
+import "math"
+
+// RSI 相对强弱：LC:=REF(C,1); RSI:=SMA(MAX(C-LC,0),N,1)/SMA(ABS(C-LC),N,1)*100
+func RSI(close []float64, n int) []float64 {
+	up := make([]float64, len(close))
+	abs := make([]float64, len(close))
+	for i := 1; i < len(close); i++ {
+		d := close[i] - close[i-1]
+		up[i] = math.Max(d, 0)
+		abs[i] = math.Abs(d)
+	}
+	su, sa := smaFrom(up, n, 1, 1), smaFrom(abs, n, 1, 1)
+	out := make([]float64, len(close))
+	for i := range out {
+		out[i] = div(su[i], sa[i]) * 100
+	}
+	return out
+}
+
+// KDJ 随机指标：RSV:=(C-LLV(L,N))/(HHV(H,N)-LLV(L,N))*100; K:=SMA(RSV,M1,1); D:=SMA(K,M2,1); J:=3*K-2*D
+func KDJ(high, low, close []float64, n, m1, m2 int) (k, d, j []float64) {
+	hh, ll := HHV(high, n), LLV(low, n)
+	rsv := make([]float64, len(close))
+	for i := range close {
+		rsv[i] = div(close[i]-ll[i], hh[i]-ll[i]) * 100
+	}
+	k = SMA(rsv, m1, 1)
+	d = SMA(k, m2, 1)
+	j = make([]float64, len(close))
+	for i := range j {
+		j[i] = 3*k[i] - 2*d[i]
+	}
+	return
+}
+
+// WR 威廉指标：100*(HHV(H,N)-C)/(HHV(H,N)-LLV(L,N))
+func WR(high, low, close []float64, n int) []float64 {
+	hh, ll := HHV(high, n), LLV(low, n)
+	out := make([]float64, len(close))
+	for i := range close {
+		out[i] = div(hh[i]-close[i], hh[i]-ll[i]) * 100
+	}
+	return out
+}
+
+// CCI 顺势指标：TYP:=(H+L+C)/3; CCI:=(TYP-MA(TYP,N))/(0.015*AVEDEV(TYP,N))
+func CCI(high, low, close []float64, n int) []float64 {
+	typ := make([]float64, len(close))
+	for i := range close {
+		typ[i] = (high[i] + low[i] + close[i]) / 3
+	}
+	ma, dev := MA(typ, n), AVEDEV(typ, n)
+	out := make([]float64, len(close))
+	for i := n - 1; i >= 0 && i < len(close); i++ {
+		out[i] = div(typ[i]-ma[i], 0.015*dev[i])
+	}
+	return out
+}
+
+// BIAS 乖离率：(C-MA(C,N))/MA(C,N)*100
+func BIAS(close []float64, n int) []float64 {
+	ma := MA(close, n)
+	out := make([]float64, len(close))
+	for i := range close {
+		if ma[i] != 0 {
+			out[i] = (close[i] - ma[i]) / ma[i] * 100
+		}
+	}
+	return out
+}
+
+// PSY 心理线：PSY:=COUNT(C>REF(C,1),N)/N*100; PSYMA:=MA(PSY,M)
+func PSY(close []float64, n, m int) (psy, psyma []float64) {
+	up := make([]float64, len(close))
+	for i := 1; i < len(close); i++ {
+		if close[i] > close[i-1] {
+			up[i] = 1
+		}
+	}
+	cnt := SUM(up, n)
+	psy = make([]float64, len(close))
+	for i := range psy {
+		psy[i] = cnt[i] / float64(n) * 100
+	}
+	return psy, MA(psy, m)
+}
+
+// TRIX 三重指数平滑：MTR:=EMA(EMA(EMA(C,N),N),N); TRIX:=(MTR-REF(MTR,1))/REF(MTR,1)*100; MATRIX:=MA(TRIX,M)
+func TRIX(close []float64, n, m int) (trix, matrix []float64) {
+	mtr := EMA(EMA(EMA(close, n), n), n)
+	trix = make([]float64, len(close))
+	for i := 1; i < len(close); i++ {
+		trix[i] = div(mtr[i]-mtr[i-1], mtr[i-1]) * 100
+	}
+	return trix, MA(trix, m)
+}
@@ -0,0 +1,283 @@
+package indicator
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Def 一个带参数的指标定义，如 ma(n)、kdj(n,m1,m2)；多输出指标用 name(...).output 引用其中一条
+type Def struct {
+	Name    string
+	Params  []float64 // 默认参数（通达信默认值）
+	Outputs []string  // 输出名，第一个为默认输出
+	Calc    func(s Series, p []float64) [][]float64
+}
+
+var registry = map[string]*Def{}
+
+// Register 注册指标定义，同名覆盖
+func Register(d Def) {
+	registry[d.Name] = &d
+}
+
+// Defs 返回所有指标定义（按名称排序）
+func Defs() []Def {
+	out := make([]Def, 0, len(registry))
+	for _, d := range registry {
+		out = append(out, *d)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func init() {
+	Register(Def{"ma", []float64{20}, []string{"ma"}, func(s Series, p []float64) [][]float64 {
+		return [][]float64{MA(s.Close, int(p[0]))}
+	}})
+	Register(Def{"ema", []float64{12}, []string{"ema"}, func(s Series, p []float64) [][]float64 {
+		return [][]float64{EMA(s.Close, int(p[0]))}
+	}})
+	Register(Def{"sma", []float64{12, 1}, []string{"sma"}, func(s Series, p []float64) [][]float64 {
+		return [][]float64{SMA(s.Close, int(p[0]), int(p[1]))}
+	}})
+	Register(Def{"vma", []float64{5}, []string{"vma"}, func(s Series, p []float64) [][]float64 {
+		return [][]float64{MA(s.Volume, int(p[0]))}
+	}})
+	Register(Def{"macd", []float64{12, 26, 9}, []string{"dif", "dea", "macd"}, func(s Series, p []float64) [][]float64 {
+		dif, dea, macd := MACD(s.Close, int(p[0]), int(p[1]), int(p[2]))
+		return [][]float64{dif, dea, macd}
+	}})
+	Register(Def{"rsi", []float64{6}, []string{"rsi"}, func(s Series, p []float64) [][]float64 {
+		return [][]float64{RSI(s.Close, int(p[0]))}
+	}})
+	Register(Def{"kdj", []float64{9, 3, 3}, []string{"k", "d", "j"}, func(s Series, p []float64) [][]float64 {
+		k, d, j := KDJ(s.High, s.Low, s.Close, int(p[0]), int(p[1]), int(p[2]))
+		return [][]float64{k, d, j}
+	}})
+	Register(Def{"boll", []float64{20, 2}, []string{"mid", "upper", "lower"}, func(s Series, p []float64) [][]float64 {
+		m, u, l := BOLL(s.Close, int(p[0]), p[1])
+		return [][]float64{m, u, l}
+	}})
+	Register(Def{"atr", []float64{14}, []string{"atr"}, func(s Series, p []float64) [][]float64 {
+		return [][]float64{ATR(s.High, s.Low, s.Close, int(p[0]))}
+	}})
+	Register(Def{"obv", nil, []string{"obv"}, func(s Series, p []float64) [][]float64 {
+		return [][]float64{OBV(s.Close, s.Volume)}
+	}})
+	Register(Def{"cci", []float64{14}, []string{"cci"}, func(s Series, p []float64) [][]float64 {
+		return [][]float64{CCI(s.High, s.Low, s.Close, int(p[0]))}
+	}})
+	Register(Def{"wr", []float64{10}, []string{"wr"}, func(s Series, p []float64) [][]float64 {
+		return [][]float64{WR(s.High, s.Low, s.Close, int(p[0]))}
+	}})
+	Register(Def{"dmi", []float64{14, 6}, []string{"pdi", "mdi", "adx", "adxr"}, func(s Series, p []float64) [][]float64 {
+		pdi, mdi, adx, adxr := DMI(s.High, s.Low, s.Close, int(p[0]), int(p[1]))
+		return [][]float64{pdi, mdi, adx, adxr}
+	}})
+	Register(Def{"bias", []float64{6}, []string{"bias"}, func(s Series, p []float64) [][]float64 {
+		return [][]float64{BIAS(s.Close, int(p[0]))}
+	}})
+	Register(Def{"psy", []float64{12, 6}, []string{"psy", "psyma"}, func(s Series, p []float64) [][]float64 {
+		psy, ma := PSY(s.Close, int(p[0]), int(p[1]))
+		return [][]float64{psy, ma}
+	}})
+	Register(Def{"vr", []float64{26, 6}, []string{"vr", "mavr"}, func(s Series, p []float64) [][]float64 {
+		vr, ma := VR(s.Close, s.Volume, int(p[0]), int(p[1]))
+		return [][]float64{vr, ma}
+	}})
+	Register(Def{"trix", []float64{12, 9}, []string{"trix", "matrix"}, func(s Series, p []float64) [][]float64 {
+		trix, ma := TRIX(s.Close, int(p[0]), int(p[1]))
+		return [][]float64{trix, ma}
+	}})
+}
+
+// Ref 解析后的指标引用：定义 + 参数 + 输出下标
+type Ref struct {
+	Def    *Def
+	Params []float64
+	Output int
+}
+
+// Key 规范名，如 ma(60)、kdj(9,3,3).k，用作缓存键
+func (r Ref) Key() string {
+	return r.base() + r.suffix()
+}
+
+func (r Ref) base() string {
+	if len(r.Params) == 0 {
+		return r.Def.Name
+	}
+	ps := make([]string, len(r.Params))
+	for i, p := range r.Params {
+		ps[i] = strconv.FormatFloat(p, 'f', -1, 64)
+	}
+	return r.Def.Name + "(" + strings.Join(ps, ",") + ")"
+}
+
+func (r Ref) suffix() string {
+	if len(r.Def.Outputs) <= 1 {
+		return ""
+	}
+	return "." + r.Def.Outputs[r.Output]
+}
+
+// aliases 扁平变量名（DSL 中不能写括号和点）到规范写法的映射
+var aliases = map[string]string{
+	"macd_dif":   "macd.dif",
+	"macd_dea":   "macd.dea",
+	"macd_hist":  "macd.macd",
+	"kdj_k":      "kdj.k",
+	"kdj_d":      "kdj.d",
+	"kdj_j":      "kdj.j",
+	"boll_mid":   "boll.mid",
+	"boll_upper": "boll.upper",
+	"boll_lower": "boll.lower",
+	"dmi_pdi":    "dmi.pdi",
+	"dmi_mdi":    "dmi.mdi",
+	"adx":        "dmi.adx",
+	"adxr":       "dmi.adxr",
+	"psyma":      "psy.psyma",
+	"mavr":       "vr.mavr",
+	"matrix":     "trix.matrix",
+}
+
+var (
+	callRe = regexp.MustCompile(`^([a-z_]+)(?:\(([^)]*)\))?(?:\.([a-z_]+))?$`)
+	flatRe = regexp.MustCompile(`^([a-z]+)(\d+)$`)
+)
+
+// maxParam 参数上限，周期超过它没有意义，也避免超大窗口分配内存
+const maxParam = 1000
+
+// floatParams 允许小数的参数（指标名 -> 参数下标），其余参数均为 1..maxParam 的整数周期
+var floatParams = map[string]int{"boll": 1}
+
+// parseParam 解析 def 的第 i 个参数
+func parseParam(def string, i int, a string) (float64, error) {
+	if j, ok := floatParams[def]; ok && j == i {
+		v, err := strconv.ParseFloat(a, 64)
+		if err != nil || !(v > 0 && v <= maxParam) {
+			return 0, fmt.Errorf("invalid param %q for %s: want a number in (0, %d]", a, def, maxParam)
+		}
+		return v, nil
+	}
+	v, err := strconv.Atoi(a)
+	if err != nil || v < 1 || v > maxParam {
+		return 0, fmt.Errorf("invalid param %q for %s: want an integer in [1, %d]", a, def, maxParam)
+	}
+	return float64(v), nil
+}
+
+// Parse 解析指标名。支持：
+//   - 规范写法：ma(60)、ema(13)、kdj(9,3,3).k、boll(20,2).upper，省略的参数取默认值，省略输出取第一个
+//   - 扁平写法（DSL 变量）：ma60、ema13、rsi6、wr10，以及 kdj_k、boll_upper、adx 等别名
+func Parse(name string) (Ref, error) {
+	name = strings.ToLower(strings.ReplaceAll(name, " ", ""))
+	if a, ok := aliases[name]; ok {
+		name = a
+	} else if m := flatRe.FindStringSubmatch(name); m != nil && registry[m[1]] != nil {
+		name = m[1] + "(" + m[2] + ")"
+	}
+	m := callRe.FindStringSubmatch(name)
+	if m == nil {
+		return Ref{}, fmt.Errorf("invalid indicator: %s", name)
+	}
+	def := registry[m[1]]
+	if def == nil {
+		return Ref{}, fmt.Errorf("unknown indicator: %s", m[1])
+	}
+	r := Ref{Def: def, Params: append([]float64(nil), def.Params...)}
+	if m[2] != "" {
+		args := strings.Split(m[2], ",")
+		if len(args) > len(def.Params) {
+			return Ref{}, fmt.Errorf("%s takes at most %d params", def.Name, len(def.Params))
+		}
+		for i, a := range args {
+			v, err := parseParam(def.Name, i, a)
+			if err != nil {
+				return Ref{}, err
+			}
+			r.Params[i] = v
+		}
+	}
+	if m[3] != "" {
+		r.Output = -1
+		for i, o := range def.Outputs {
+			if o == m[3] {
+				r.Output = i
+			}
+		}
+		if r.Output < 0 {
+			return Ref{}, fmt.Errorf("%s has no output %s (outputs: %s)", def.Name, m[3], strings.Join(def.Outputs, ", "))
+		}
+	}
+	return r, nil
+}
+
+// groups 常用指标组（/api/kline?indicators=rsi,kdj），组名 -> 输出标签 -> 指标名
+var groups = map[string][][2]string{
+	"ma":   {{"ma5", "ma(5)"}, {"ma10", "ma(10)"}, {"ma20", "ma(20)"}, {"ma30", "ma(30)"}},
+	"macd": {{"macd_dif", "macd.dif"}, {"macd_dea", "macd.dea"}, {"macd_hist", "macd.macd"}},
+	"rsi":  {{"rsi6", "rsi(6)"}, {"rsi12", "rsi(12)"}, {"rsi24", "rsi(24)"}},
+	"kdj":  {{"kdj_k", "kdj.k"}, {"kdj_d", "kdj.d"}, {"kdj_j", "kdj.j"}},
+	"boll": {{"boll_mid", "boll.mid"}, {"boll_upper", "boll.upper"}, {"boll_lower", "boll.lower"}},
+	"atr":  {{"atr", "atr"}},
+	"obv":  {{"obv", "obv"}},
+	"cci":  {{"cci", "cci"}},
+	"wr":   {{"wr10", "wr(10)"}, {"wr6", "wr(6)"}},
+	"dmi":  {{"dmi_pdi", "dmi.pdi"}, {"dmi_mdi", "dmi.mdi"}, {"adx", "dmi.adx"}, {"adxr", "dmi.adxr"}},
+	"bias": {{"bias6", "bias(6)"}, {"bias12", "bias(12)"}, {"bias24", "bias(24)"}},
+	"psy":  {{"psy", "psy"}, {"psyma", "psy.psyma"}},
+	"vr":   {{"vr", "vr"}, {"mavr", "vr.mavr"}},
+	"trix": {{"trix", "trix"}, {"matrix", "trix.matrix"}},
+}
+
+// Names 返回指标组名（rsi / kdj / boll ...）
+func Names() []string {
+	out := make([]string, 0, len(groups))
+	for name := range groups {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Outputs 返回指标组的输出标签，未知组返回 nil
+func Outputs(group string) []string {
+	var out []string
+	for _, o := range groups[strings.ToLower(group)] {
+		out = append(out, o[0])
+	}
+	return out
+}
+
+// SplitNames 按逗号拆分指标列表，括号内的逗号不拆：rsi,kdj(9,3,3).k -> [rsi kdj(9,3,3).k]
+func SplitNames(s string) []string {
+	var out []string
+	depth, start := 0, 0
+	for i, r := range s + "," {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				if v := strings.TrimSpace(s[start:i]); v != "" {
+					out = append(out, v)
+				}
+				start = i + 1
+			}
+		}
+	}
+	return out
+}
+
+// Compute 计算指标，names 可以是指标组名（输出组内全部标签）或任意指标名（以原样作为标签）；
+// names 为空时计算全部指标组
+func Compute(s Series, names ...string) (map[string][]float64, error) {
+	return NewFrame(s).Compute(names...)
+}
@@ -0,0 +1,62 @@
+package indicator
+
+import "testing"
+
+func TestParseParams(t *testing.T) {
+	cases := []struct {
+		name string
+		key  string // 为空表示应当报错
+	}{
+		{"ma60", "ma(60)"},
+		{"wr(10)", "wr(10)"},
+		{"kdj(9,3,3).k", "kdj(9,3,3).k"},
+		{"boll(20,2.5).upper", "boll(20,2.5).upper"},
+		{"ma(1000)", "ma(1000)"},
+		{"wr(99999999999)", ""},
+		{"wr99999999999", ""},
+		{"ma(1001)", ""},
+		{"wr(0.5)", ""},
+		{"kdj(0.5)", ""},
+		{"cci(0.5)", ""},
+		{"psy(0.5)", ""},
+		{"ma(0)", ""},
+		{"ma(-5)", ""},
+		{"boll(20.5)", ""},
+		{"boll(20,0)", ""},
+		{"boll(20,1e9)", ""},
+	}
+	for _, c := range cases {
+		r, err := Parse(c.name)
+		if c.key == "" {
+			if err == nil {
+				t.Errorf("Parse(%q) = %s, want error", c.name, r.Key())
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q): %v", c.name, err)
+			continue
+		}
+		if got := r.Key(); got != c.key {
+			t.Errorf("Parse(%q).Key() = %q, want %q", c.name, got, c.key)
+		}
+	}
+}
+
+// 窗口大于序列长度或非正时不应 panic，也不应按窗口大小分配内存
+func TestWindowBounds(t *testing.T) {
+	x := []float64{3, 1, 4, 1, 5}
+	for _, n := range []int{-1, 0, 1, 5, 1 << 40} {
+		hh, ll, dev, ref := HHV(x, n), LLV(x, n), AVEDEV(x, n), REF(x, n)
+		if len(hh) != len(x) || len(ll) != len(x) || len(dev) != len(x) || len(ref) != len(x) {
+			t.Fatalf("n=%d: length mismatch", n)
+		}
+		if n >= len(x) && (hh[4] != 5 || ll[4] != 1) {
+			t.Errorf("n=%d: HHV/LLV last = %v/%v", n, hh[4], ll[4])
+		}
+	}
+	s := Series{Open: x, High: x, Low: x, Close: x, Volume: x}
+	if _, err := Compute(s, "wr(1000)", "kdj(1000).j", "cci(1000)", "psy(1000)"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,88 @@
+package indicator
+
+import "math"
+
+// BOLL 布林线：MID:=MA(C,N); UPPER:=MID+P*STD(C,N); LOWER:=MID-P*STD(C,N)
+func BOLL(close []float64, n int, p float64) (mid, upper, lower []float64) {
+	mid = MA(close, n)
+	std := STD(close, n)
+	upper = make([]float64, len(close))
+	lower = make([]float64, len(close))
+	for i := n - 1; i >= 0 && i < len(close); i++ {
+		upper[i] = mid[i] + p*std[i]
+		lower[i] = mid[i] - p*std[i]
+	}
+	return
+}
+
+// trueRange TR:=MAX(MAX(H-L,ABS(REF(C,1)-H)),ABS(REF(C,1)-L))，首根为 H-L
+func trueRange(high, low, close []float64) []float64 {
+	tr := make([]float64, len(close))
+	for i := range close {
+		tr[i] = high[i] - low[i]
+		if i > 0 {
+			tr[i] = math.Max(tr[i], math.Max(math.Abs(close[i-1]-high[i]), math.Abs(close[i-1]-low[i])))
+		}
+	}
+	return tr
+}
+
+// ATR 真实波幅均值：ATR:=MA(TR,N)
+func ATR(high, low, close []float64, n int) []float64 {
+	return MA(trueRange(high, low, close), n)
+}
+
+// DMI 趋向指标：
+//
+//	MTR:=SUM(TR,N); HD:=H-REF(H,1); LD:=REF(L,1)-L;
+//	DMP:=SUM(IF(HD>0&&HD>LD,HD,0),N); DMM:=SUM(IF(LD>0&&LD>HD,LD,0),N);
+//	PDI:=DMP*100/MTR; MDI:=DMM*100/MTR;
+//	ADX:=MA(ABS(MDI-PDI)/(MDI+PDI)*100,M); ADXR:=(ADX+REF(ADX,M))/2
+func DMI(high, low, close []float64, n, m int) (pdi, mdi, adx, adxr []float64) {
+	size := len(close)
+	mtr := SUM(trueRange(high, low, close), n)
+	dp := make([]float64, size)
+	dm := make([]float64, size)
+	for i := 1; i < size; i++ {
+		hd := high[i] - high[i-1]
+		ld := low[i-1] - low[i]
+		if hd > 0 && hd > ld {
+			dp[i] = hd
+		}
+		if ld > 0 && ld > hd {
+			dm[i] = ld
+		}
+	}
+	dmp, dmm := SUM(dp, n), SUM(dm, n)
+	pdi = make([]float64, size)
+	mdi = make([]float64, size)
+	dx := make([]float64, size)
+	for i := 0; i < size; i++ {
+		pdi[i] = div(dmp[i]*100, mtr[i])
+		mdi[i] = div(dmm[i]*100, mtr[i])
+		dx[i] = div(math.Abs(mdi[i]-pdi[i]), mdi[i]+pdi[i]) * 100
+	}
+	adx = MA(dx, m)
+	adxr = make([]float64, size)
+	for i := m; i < size; i++ {
+		if adx[i-m] != 0 {
+			adxr[i] = (adx[i] + adx[i-m]) / 2
+		}
+	}
+	return
+}
+
+// MACD 指数平滑异同平均：DIF:=EMA(C,SHORT)-EMA(C,LONG); DEA:=EMA(DIF,MID); MACD:=(DIF-DEA)*2
+func MACD(close []float64, short, long, mid int) (dif, dea, macd []float64) {
+	es, el := EMA(close, short), EMA(close, long)
+	dif = make([]float64, len(close))
+	for i := range close {
+		dif[i] = es[i] - el[i]
+	}
+	dea = EMA(dif, mid)
+	macd = make([]float64, len(close))
+	for i := range close {
+		macd[i] = (dif[i] - dea[i]) * 2
+	}
+	return
+}
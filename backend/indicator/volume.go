@@ -0,0 +1,43 @@
+package indicator
+
+// OBV 能量潮：SUM(IF(C>REF(C,1),V,IF(C<REF(C,1),-V,0)),0)
+func OBV(close, volume []float64) []float64 {
+	out := make([]float64, len(close))
+	for i := 1; i < len(close); i++ {
+		out[i] = out[i-1]
+		switch {
+		case close[i] > close[i-1]:
+			out[i] += volume[i]
+		case close[i] < close[i-1]:
+			out[i] -= volume[i]
+		}
+	}
+	return out
+}
+
+// VR 成交量变异率：
+//
+//	TH:=SUM(IF(C>REF(C,1),V,0),N); TL:=SUM(IF(C<REF(C,1),V,0),N); TQ:=SUM(IF(C=REF(C,1),V,0),N);
+//	VR:=100*(TH*2+TQ)/(TL*2+TQ); MAVR:=MA(VR,M)
+func VR(close, volume []float64, n, m int) (vr, mavr []float64) {
+	size := len(close)
+	up := make([]float64, size)
+	dn := make([]float64, size)
+	eq := make([]float64, size)
+	for i := 1; i < size; i++ {
+		switch {
+		case close[i] > close[i-1]:
+			up[i] = volume[i]
+		case close[i] < close[i-1]:
+			dn[i] = volume[i]
+		default:
+			eq[i] = volume[i]
+		}
+	}
+	th, tl, tq := SUM(up, n), SUM(dn, n), SUM(eq, n)
+	vr = make([]float64, size)
+	for i := 0; i < size; i++ {
+		vr[i] = div(100*(th[i]*2+tq[i]), tl[i]*2+tq[i])
+	}
+	return vr, MA(vr, m)
+}
@@ -0,0 +1,67 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"go-stock-analyzer/backend/config"
+	"go-stock-analyzer/backend/fetcher"
+	"go-stock-analyzer/backend/storage"
+	"go-stock-analyzer/backend/upstream"
+)
+
+// JobBackfill 全市场历史日 K 线回补任务
+const JobBackfill = "backfill"
+
+// breakerWait 上游熔断时的等待间隔，等待期间不计入失败次数
+const breakerWait = 10 * time.Second
+
+func init() {
+	Register(&Spec{Name: JobBackfill, Symbols: backfillSymbols, Run: backfillOne})
+}
+
+// backfillSymbols 从证券主表选取在市证券（默认 A 股，可按板块/类型过滤）
+func backfillSymbols(p Params) ([]string, error) {
+	if len(p.Symbols) > 0 {
+		return p.Symbols, nil
+	}
+	f := storage.StockFilter{Board: p.Board, SecType: p.SecType}
+	list, _, err := storage.QueryStocksFilter(f, 0, 1000000)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, 0, len(list))
+	for _, s := range list {
+		out = append(out, s.Symbol)
+	}
+	return out, nil
+}
+
+// backfillOne 增量同步单只证券的日 K 线与复权因子；
+// 限速与重试由 upstream 客户端处理，熔断打开时等待恢复后重试
+func backfillOne(ctx context.Context, symbol string, p Params) (int, error) {
+	days := p.Days
+	if days <= 0 {
+		days = config.Cfg.WatchlistKlineDays
+	}
+	for {
+		n, err := fetcher.SyncKLine(symbol, days)
+		if err != nil && errors.Is(err, upstream.ErrCircuitOpen) {
+			select {
+			case <-ctx.Done():
+				return 0, ctx.Err()
+			case <-time.After(breakerWait):
+				continue
+			}
+		}
+		if err != nil {
+			return 0, err
+		}
+		if _, err := fetcher.UpdateAdjFactors(symbol); err != nil {
+			log.Printf("backfill: update adj factors %s error: %v", symbol, err)
+		}
+		return n, nil
+	}
+}
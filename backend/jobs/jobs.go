@@ -0,0 +1,328 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"go-stock-analyzer/backend/storage"
+)
+
+// maxAttempts 单只证券最多尝试次数（续跑时失败次数未达上限的会重试）
+const maxAttempts = 3
+
+// Params 任务参数，随任务记录持久化，续跑时沿用
+type Params struct {
+	Symbols     []string `json:"symbols,omitempty"`     // 指定证券，为空时按 Board/SecType 从证券主表选取
+	Board       string   `json:"board,omitempty"`       // 板块过滤
+	SecType     string   `json:"sec_type,omitempty"`    // 证券类型过滤，默认 stock
+	Days        int      `json:"days,omitempty"`        // 回补天数
+	Concurrency int      `json:"concurrency,omitempty"` // 并发数
+	DryRun      bool     `json:"dry_run,omitempty"`     // 只统计不写库（recompute）
+}
+
+// Spec 任务定义
+type Spec struct {
+	Name string
+	// Symbols 根据参数返回任务要处理的证券
+	Symbols func(p Params) ([]string, error)
+	// Run 处理单只证券，返回写入的行数
+	Run func(ctx context.Context, symbol string, p Params) (int, error)
+}
+
+// Status 任务进度
+type Status struct {
+	Name       string            `json:"name"`
+	State      string            `json:"state"`
+	Params     Params            `json:"params"`
+	Total      int               `json:"total"`
+	Done       int               `json:"done"`
+	Failed     int               `json:"failed"`
+	Pending    int               `json:"pending"`
+	Rows       int               `json:"rows"` // 写入的行数；recompute 为指标有变化（dry_run 时为将要变化）的行数
+	Current    []string          `json:"current"`
+	Rate       float64           `json:"rate_per_min"` // 本次运行的处理速度（只/分钟）
+	ETA        string            `json:"eta"`
+	Error      string            `json:"error"`
+	Failures   map[string]string `json:"failures,omitempty"`
+	StartedAt  string            `json:"started_at"`
+	UpdatedAt  string            `json:"updated_at"`
+	FinishedAt string            `json:"finished_at"`
+}
+
+// run 正在运行的任务实例
+type run struct {
+	cancel    context.CancelFunc
+	done      chan struct{}
+	startedAt time.Time
+	processed int
+	current   map[string]bool
+}
+
+var (
+	mu      sync.Mutex
+	specs   = map[string]*Spec{}
+	running = map[string]*run{}
+)
+
+// Register 注册任务定义
+func Register(s *Spec) {
+	mu.Lock()
+	defer mu.Unlock()
+	specs[s.Name] = s
+}
+
+// Names 返回已注册的任务名
+func Names() []string {
+	mu.Lock()
+	defer mu.Unlock()
+	out := make([]string, 0, len(specs))
+	for n := range specs {
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Start 启动任务。上次运行未完成（停止、失败或进程中断）且 restart 为 false 时沿用原参数从检查点续跑，
+// 否则（或 dry_run 与上次不同时）按 p 重新生成证券列表。
+func Start(name string, p Params, restart bool) (*Status, error) {
+	return launch(name, p, restart, false)
+}
+
+// launch 启动任务；resume 为 true 表示进程重启后自动续跑，总是沿用任务记录中保存的参数
+func launch(name string, p Params, restart, resume bool) (*Status, error) {
+	mu.Lock()
+	spec, ok := specs[name]
+	if !ok {
+		mu.Unlock()
+		return nil, fmt.Errorf("unknown job: %s", name)
+	}
+	if _, busy := running[name]; busy {
+		mu.Unlock()
+		return nil, fmt.Errorf("job %s is already running", name)
+	}
+	// cancel 在登记前创建，prepare 期间并发的 Stop 也能安全取消
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &run{cancel: cancel, done: make(chan struct{}), startedAt: time.Now(), current: map[string]bool{}}
+	running[name] = r
+	mu.Unlock()
+
+	symbols, params, err := prepare(spec, p, restart, resume)
+	if err != nil {
+		cancel()
+		mu.Lock()
+		delete(running, name)
+		mu.Unlock()
+		close(r.done)
+		return nil, err
+	}
+	go execute(ctx, spec, r, symbols, params)
+	return GetStatus(name)
+}
+
+// prepare 决定续跑还是重新开始，返回本次要处理的证券与参数
+func prepare(spec *Spec, p Params, restart, resume bool) ([]string, Params, error) {
+	rec, err := storage.GetJob(spec.Name)
+	if err != nil {
+		return nil, p, err
+	}
+	if rec != nil && rec.State != storage.JobFinished && !restart {
+		var saved Params
+		if err := json.Unmarshal([]byte(rec.Params), &saved); err != nil {
+			return nil, p, err
+		}
+		// 用户显式启动且 dry_run 与上次不同时不能续跑：否则试运行会接着写库，或正式运行跳过试运行已统计过的证券。
+		// 自动续跑（resume）不带参数，必须沿用保存的 dry_run
+		if !resume && saved.DryRun != p.DryRun {
+			log.Printf("job %s: dry_run changed (%v -> %v), restarting", spec.Name, saved.DryRun, p.DryRun)
+			return start(spec, p)
+		}
+		if p.Concurrency > 0 {
+			saved.Concurrency = p.Concurrency
+		}
+		pending, err := storage.PendingJobSymbols(spec.Name, maxAttempts)
+		if err != nil {
+			return nil, saved, err
+		}
+		if err := storage.SetJobState(spec.Name, storage.JobRunning, ""); err != nil {
+			return nil, saved, err
+		}
+		log.Printf("job %s: resuming, %d symbols left", spec.Name, len(pending))
+		return pending, saved, nil
+	}
+	return start(spec, p)
+}
+
+// start 按 p 重新生成证券列表并重置检查点
+func start(spec *Spec, p Params) ([]string, Params, error) {
+	symbols, err := spec.Symbols(p)
+	if err != nil {
+		return nil, p, err
+	}
+	b, _ := json.Marshal(p)
+	if err := storage.ResetJob(spec.Name, string(b), symbols); err != nil {
+		return nil, p, err
+	}
+	log.Printf("job %s: started, %d symbols", spec.Name, len(symbols))
+	return symbols, p, nil
+}
+
+func execute(ctx context.Context, spec *Spec, r *run, symbols []string, p Params) {
+	defer func() {
+		mu.Lock()
+		delete(running, spec.Name)
+		mu.Unlock()
+		close(r.done)
+	}()
+	conc := p.Concurrency
+	if conc <= 0 {
+		conc = 1
+	}
+	ch := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < conc; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for sym := range ch {
+				mu.Lock()
+				r.current[sym] = true
+				mu.Unlock()
+				n, err := spec.Run(ctx, sym, p)
+				mu.Lock()
+				delete(r.current, sym)
+				mu.Unlock()
+				if err != nil && ctx.Err() != nil {
+					// 被停止而中断：本只保持待处理，续跑时重做
+					continue
+				}
+				status, errStr := storage.CheckpointDone, ""
+				if err != nil {
+					status, errStr = storage.CheckpointFailed, err.Error()
+					log.Printf("job %s: %s failed: %v", spec.Name, sym, err)
+				}
+				if err := storage.SetJobCheckpoint(spec.Name, sym, status, n, errStr); err != nil {
+					log.Printf("job %s: save checkpoint %s error: %v", spec.Name, sym, err)
+				}
+				mu.Lock()
+				r.processed++
+				mu.Unlock()
+			}
+		}()
+	}
+feed:
+	for _, sym := range symbols {
+		select {
+		case <-ctx.Done():
+			break feed
+		case ch <- sym:
+		}
+	}
+	close(ch)
+	wg.Wait()
+
+	state := storage.JobFinished
+	if ctx.Err() != nil {
+		state = storage.JobStopped
+	}
+	if err := storage.SetJobState(spec.Name, state, ""); err != nil {
+		log.Printf("job %s: save state error: %v", spec.Name, err)
+	}
+	log.Printf("job %s: %s", spec.Name, state)
+}
+
+// Stop 停止任务并等待正在处理的证券结束
+func Stop(name string) error {
+	mu.Lock()
+	r, ok := running[name]
+	mu.Unlock()
+	if !ok {
+		return fmt.Errorf("job %s is not running", name)
+	}
+	r.cancel()
+	<-r.done
+	return nil
+}
+
+// GetStatus 返回任务进度（检查点统计 + 运行中的实时信息）
+func GetStatus(name string) (*Status, error) {
+	mu.Lock()
+	_, known := specs[name]
+	mu.Unlock()
+	if !known {
+		return nil, fmt.Errorf("unknown job: %s", name)
+	}
+	rec, err := storage.GetJob(name)
+	if err != nil {
+		return nil, err
+	}
+	st := &Status{Name: name, State: "idle", Current: []string{}}
+	if rec == nil {
+		return st, nil
+	}
+	st.State, st.Error = rec.State, rec.Error
+	st.StartedAt, st.UpdatedAt, st.FinishedAt = rec.StartedAt, rec.UpdatedAt, rec.FinishedAt
+	_ = json.Unmarshal([]byte(rec.Params), &st.Params)
+	counts, rows, err := storage.JobProgress(name)
+	if err != nil {
+		return nil, err
+	}
+	st.Done, st.Failed, st.Pending = counts[storage.CheckpointDone], counts[storage.CheckpointFailed], counts[storage.CheckpointPending]
+	st.Total = st.Done + st.Failed + st.Pending
+	st.Rows = rows
+	if st.Failed > 0 {
+		if st.Failures, err = storage.JobFailures(name, 20); err != nil {
+			return nil, err
+		}
+	}
+
+	mu.Lock()
+	r, active := running[name]
+	if active {
+		for s := range r.current {
+			st.Current = append(st.Current, s)
+		}
+		if mins := time.Since(r.startedAt).Minutes(); mins > 0 && r.processed > 0 {
+			st.Rate = float64(r.processed) / mins
+			st.ETA = (time.Duration(float64(st.Pending)/st.Rate*float64(time.Minute)) / time.Second * time.Second).String()
+		}
+	}
+	mu.Unlock()
+	if !active && st.State == storage.JobRunning {
+		// 记录为运行中但进程内没有实例：上次进程在运行中退出
+		st.State = "interrupted"
+	}
+	sort.Strings(st.Current)
+	return st, nil
+}
+
+// List 返回所有已注册任务的进度
+func List() ([]*Status, error) {
+	out := []*Status{}
+	for _, n := range Names() {
+		st, err := GetStatus(n)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, st)
+	}
+	return out, nil
+}
+
+// ResumeInterrupted 启动时续跑上次进程退出时仍处于运行中的任务
+func ResumeInterrupted() {
+	for _, n := range Names() {
+		rec, err := storage.GetJob(n)
+		if err != nil || rec == nil || rec.State != storage.JobRunning {
+			continue
+		}
+		if _, err := launch(n, Params{}, false, true); err != nil {
+			log.Printf("job %s: resume failed: %v", n, err)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package jobs
+
+import (
+	"context"
+	"math"
+
+	"go-stock-analyzer/backend/fetcher"
+	"go-stock-analyzer/backend/storage"
+)
+
+// JobRecompute 用库中 OHLCV 重算全部已存 K 线的指标列（MA5/10/20/30、DIF/DEA/MACD），
+// 用于指标公式变更后修正旧数据。DryRun 时只统计与重算结果不一致的行数，不写库。
+const JobRecompute = "recompute"
+
+// recomputeEpsilon 判定指标值不一致的相对误差
+const recomputeEpsilon = 1e-6
+
+func init() {
+	Register(&Spec{Name: JobRecompute, Symbols: recomputeSymbols, Run: recomputeOne})
+}
+
+// recomputeSymbols 默认处理 kline 表中的全部代码
+func recomputeSymbols(p Params) ([]string, error) {
+	if len(p.Symbols) > 0 {
+		return p.Symbols, nil
+	}
+	return storage.KLineSymbols()
+}
+
+// recomputeOne 重算单只证券，返回指标有变化的行数；变化的行在一个事务中写回
+func recomputeOne(ctx context.Context, symbol string, p Params) (int, error) {
+	stored, err := storage.LoadAllKLines(symbol)
+	if err != nil {
+		return 0, err
+	}
+	fresh := make([]storage.KLine, len(stored))
+	copy(fresh, stored)
+	fetcher.ComputeIndicators(fresh)
+	changed := []storage.KLine{}
+	for i := range stored {
+		if indicatorsDiffer(stored[i], fresh[i]) {
+			changed = append(changed, fresh[i])
+		}
+	}
+	if p.DryRun || len(changed) == 0 {
+		return len(changed), nil
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if err := storage.UpdateKLineIndicators(symbol, changed); err != nil {
+		return 0, err
+	}
+	return len(changed), nil
+}
+
+func indicatorsDiffer(a, b storage.KLine) bool {
+	av := []float64{a.MA5, a.MA10, a.MA20, a.MA30, a.DIF, a.DEA, a.MACD}
+	bv := []float64{b.MA5, b.MA10, b.MA20, b.MA30, b.DIF, b.DEA, b.MACD}
+	for i := range av {
+		if math.Abs(av[i]-bv[i]) > recomputeEpsilon*math.Max(1, math.Abs(bv[i])) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+
+	"go-stock-analyzer/backend/config"
+	"go-stock-analyzer/backend/fetcher"
+	"go-stock-analyzer/backend/tdx"
+)
+
+// JobTDXImport 把 tdx_dir 下的通达信日线文件导入 kline 表
+const JobTDXImport = "tdx_import"
+
+func init() {
+	Register(&Spec{Name: JobTDXImport, Symbols: tdxSymbols, Run: tdxImportOne})
+}
+
+// tdxSymbols 目录下全部日线文件，指定 symbols 时只导入这些
+func tdxSymbols(p Params) ([]string, error) {
+	if config.Cfg.TDXDir == "" {
+		return nil, fmt.Errorf("tdx_dir not configured")
+	}
+	if len(p.Symbols) > 0 {
+		return p.Symbols, nil
+	}
+	return tdx.ListSymbols(config.Cfg.TDXDir)
+}
+
+// tdxImportOne 读取单个 .day 文件，与库中数据合并并重算指标
+func tdxImportOne(ctx context.Context, symbol string, p Params) (int, error) {
+	klines, err := tdx.ReadDayFile(config.Cfg.TDXDir, symbol)
+	if err != nil {
+		return 0, err
+	}
+	if p.Days > 0 && len(klines) > p.Days {
+		klines = klines[len(klines)-p.Days:]
+	}
+	_, n, err := fetcher.ImportKLines(symbol, klines, false)
+	return n, err
+}
@@ -0,0 +1,194 @@
+package klinecsv
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-stock-analyzer/backend/storage"
+)
+
+// 基础列与 predict/data/stock_history.csv 一致；指标列可选
+var (
+	BaseColumns      = []string{"Date", "Open", "High", "Low", "Close", "Volume"}
+	IndicatorColumns = []string{"MA5", "MA10", "MA20", "MA30", "DIF", "DEA", "MACD"}
+)
+
+// WriteOptions 导出选项
+type WriteOptions struct {
+	Indicators bool // 追加指标列
+	Symbol     bool // 首列为 Symbol（批量导出）
+}
+
+// Writer 逐只证券写出 K 线 CSV，表头只写一次
+type Writer struct {
+	w      *csv.Writer
+	opt    WriteOptions
+	header bool
+}
+
+// NewWriter 创建 CSV 写出器
+func NewWriter(w io.Writer, opt WriteOptions) *Writer {
+	return &Writer{w: csv.NewWriter(w), opt: opt}
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// Write 写出一只证券的 K 线
+func (cw *Writer) Write(symbol string, klines []storage.KLine) error {
+	if !cw.header {
+		h := []string{}
+		if cw.opt.Symbol {
+			h = append(h, "Symbol")
+		}
+		h = append(h, BaseColumns...)
+		if cw.opt.Indicators {
+			h = append(h, IndicatorColumns...)
+		}
+		if err := cw.w.Write(h); err != nil {
+			return err
+		}
+		cw.header = true
+	}
+	for _, k := range klines {
+		row := []string{}
+		if cw.opt.Symbol {
+			row = append(row, symbol)
+		}
+		row = append(row, k.Date, formatFloat(k.Open), formatFloat(k.High), formatFloat(k.Low), formatFloat(k.Close), formatFloat(k.Volume))
+		if cw.opt.Indicators {
+			for _, v := range []float64{k.MA5, k.MA10, k.MA20, k.MA30, k.DIF, k.DEA, k.MACD} {
+				// 指标保留 4 位小数，去掉浮点误差
+				row = append(row, formatFloat(math.Round(v*1e4)/1e4))
+			}
+		}
+		if err := cw.w.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// Write 把一只证券的 K 线写为 CSV
+func Write(w io.Writer, symbol string, klines []storage.KLine, opt WriteOptions) error {
+	return NewWriter(w, opt).Write(symbol, klines)
+}
+
+// headerAliases 列名别名（不区分大小写），兼容常见的中文表头
+var headerAliases = map[string]string{
+	"date": "Date", "日期": "Date", "trade_date": "Date",
+	"open": "Open", "开盘": "Open", "开盘价": "Open",
+	"high": "High", "最高": "High", "最高价": "High",
+	"low": "Low", "最低": "Low", "最低价": "Low",
+	"close": "Close", "收盘": "Close", "收盘价": "Close",
+	"volume": "Volume", "vol": "Volume", "成交量": "Volume",
+	"symbol": "Symbol", "code": "Symbol", "代码": "Symbol",
+}
+
+// dateLayouts 支持的日期写法，统一转换为 YYYY-MM-DD
+var dateLayouts = []string{"2006-01-02", "2006/1/2", "2006-1-2", "20060102", "2006/01/02"}
+
+// NormalizeDate 把 2023/1/1、20230101 等写法统一为 2023-01-01
+func NormalizeDate(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if len(s) > 10 && (s[10] == ' ' || s[10] == 'T') {
+		s = s[:10]
+	}
+	for _, l := range dateLayouts {
+		if t, err := time.Parse(l, s); err == nil {
+			return t.Format("2006-01-02"), nil
+		}
+	}
+	return "", fmt.Errorf("invalid date %q", s)
+}
+
+// Read 解析 K 线 CSV，返回按证券分组、日期升序的 K 线。
+// 没有 Symbol 列时所有行归入 defaultSymbol；指标列会被忽略（导入后重新计算）。
+func Read(r io.Reader, defaultSymbol string) (map[string][]storage.KLine, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	idx := map[string]int{}
+	for i, h := range header {
+		h = strings.TrimSpace(strings.TrimPrefix(h, "\ufeff"))
+		if name, ok := headerAliases[strings.ToLower(h)]; ok {
+			idx[name] = i
+		}
+	}
+	for _, c := range BaseColumns {
+		if _, ok := idx[c]; !ok {
+			return nil, fmt.Errorf("missing column %s", c)
+		}
+	}
+	symIdx, hasSym := idx["Symbol"]
+	if !hasSym && defaultSymbol == "" {
+		return nil, fmt.Errorf("symbol required: no Symbol column in csv")
+	}
+	out := map[string][]storage.KLine{}
+	line := 1
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if len(rec) == 1 && strings.TrimSpace(rec[0]) == "" {
+			continue
+		}
+		get := func(col string) string {
+			if i := idx[col]; i < len(rec) {
+				return strings.TrimSpace(rec[i])
+			}
+			return ""
+		}
+		sym := defaultSymbol
+		if hasSym && symIdx < len(rec) && strings.TrimSpace(rec[symIdx]) != "" {
+			sym = strings.TrimSpace(rec[symIdx])
+		}
+		date, err := NormalizeDate(get("Date"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		k := storage.KLine{Code: sym, Date: date}
+		for _, f := range []struct {
+			col string
+			dst *float64
+		}{{"Open", &k.Open}, {"High", &k.High}, {"Low", &k.Low}, {"Close", &k.Close}, {"Volume", &k.Volume}} {
+			v, err := strconv.ParseFloat(get(f.col), 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid %s %q", line, f.col, get(f.col))
+			}
+			*f.dst = v
+		}
+		out[sym] = append(out[sym], k)
+	}
+	for sym, ks := range out {
+		sort.SliceStable(ks, func(i, j int) bool { return ks[i].Date < ks[j].Date })
+		// 同一日期出现多次时保留最后一行
+		dedup := ks[:0]
+		for _, k := range ks {
+			if n := len(dedup); n > 0 && dedup[n-1].Date == k.Date {
+				dedup[n-1] = k
+				continue
+			}
+			dedup = append(dedup, k)
+		}
+		out[sym] = dedup
+	}
+	return out, nil
+}
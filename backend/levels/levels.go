@@ -0,0 +1,331 @@
+package levels
+
+import (
+	"math"
+	"sort"
+
+	"go-stock-analyzer/backend/pattern"
+	"go-stock-analyzer/backend/storage"
+)
+
+// 区间来源
+const (
+	SourcePivot  = "pivot"  // 波段高低点聚类
+	SourceVolume = "volume" // 成交密集区（volume-at-price 高成交量节点）
+	SourceGap    = "gap"    // 尚未回补的跳空缺口
+)
+
+const (
+	Support    = "support"
+	Resistance = "resistance"
+)
+
+// Zone 支撑/阻力区间
+type Zone struct {
+	Kind     string   `json:"kind"` // support | resistance（相对最新收盘价）
+	Low      float64  `json:"low"`
+	High     float64  `json:"high"`
+	Price    float64  `json:"price"`    // 区间中心（按权重）
+	Touches  int      `json:"touches"`  // 波段点触及次数
+	Volume   float64  `json:"volume"`   // 区间内成交量占比（%）
+	Sources  []string `json:"sources"`  // pivot / volume / gap
+	Strength float64  `json:"strength"` // 0~100
+	LastDate string   `json:"last_date"`
+	Distance float64  `json:"distance"` // 相对最新收盘价的距离（%，正为上方）
+}
+
+// Options 识别参数
+type Options struct {
+	SwingWindow int     // 波段点左右确认的 K 线根数
+	ClusterPct  float64 // 价位相差不超过该比例的候选合并为一个区间
+	Bins        int     // volume-at-price 价格分箱数
+	MaxZones    int     // 每一侧最多返回的区间数
+}
+
+// DefaultNearPct near_support / near_resistance 的默认判定距离（%）
+const DefaultNearPct = 2.0
+
+func DefaultOptions() Options {
+	return Options{SwingWindow: 5, ClusterPct: 0.015, Bins: 60, MaxZones: 5}
+}
+
+// Result 一只证券的支撑阻力分析结果
+type Result struct {
+	Close       float64 `json:"close"`
+	Date        string  `json:"date"`
+	Supports    []Zone  `json:"supports"`    // 由近到远
+	Resistances []Zone  `json:"resistances"` // 由近到远
+}
+
+// candidate 一个价位候选：来源、价格区间与权重
+type candidate struct {
+	source    string
+	low, high float64
+	weight    float64
+	index     int
+}
+
+// Analyze 从 K 线（按日期升序）计算支撑阻力区间
+func Analyze(klines []storage.KLine, opt Options) *Result {
+	res := &Result{Supports: []Zone{}, Resistances: []Zone{}}
+	if len(klines) == 0 {
+		return res
+	}
+	last := klines[len(klines)-1]
+	res.Close, res.Date = last.Close, last.Date
+	var cands []candidate
+	cands = append(cands, pivots(klines, opt)...)
+	cands = append(cands, volumeNodes(klines, opt)...)
+	cands = append(cands, gaps(klines)...)
+	zones := cluster(klines, cands, opt)
+	total := 0.0
+	for _, k := range klines {
+		total += k.Volume
+	}
+	for _, z := range zones {
+		z.Volume = round2(volumeIn(klines, z.Low, z.High) / math.Max(total, 1) * 100)
+		z.Distance = round2((z.Price/last.Close - 1) * 100)
+		// 收盘价位于区间内时按区间中心划分
+		if z.Price <= last.Close {
+			z.Kind = Support
+			res.Supports = append(res.Supports, z)
+		} else {
+			z.Kind = Resistance
+			res.Resistances = append(res.Resistances, z)
+		}
+	}
+	sort.Slice(res.Supports, func(i, j int) bool { return res.Supports[i].Price > res.Supports[j].Price })
+	sort.Slice(res.Resistances, func(i, j int) bool { return res.Resistances[i].Price < res.Resistances[j].Price })
+	if opt.MaxZones > 0 {
+		res.Supports = strongest(res.Supports, opt.MaxZones)
+		res.Resistances = strongest(res.Resistances, opt.MaxZones)
+	}
+	return res
+}
+
+// strongest 保留强度最高的 n 个区间，保持由近到远的顺序
+func strongest(zs []Zone, n int) []Zone {
+	if len(zs) <= n {
+		return zs
+	}
+	idx := make([]int, len(zs))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return zs[idx[a]].Strength > zs[idx[b]].Strength })
+	idx = idx[:n]
+	sort.Ints(idx)
+	out := make([]Zone, 0, n)
+	for _, i := range idx {
+		out = append(out, zs[i])
+	}
+	return out
+}
+
+// pivots 波段高低点（上下各留 ClusterPct/4 的宽度），越近的权重越高（最早的为 0.5，最新的为 1）
+func pivots(klines []storage.KLine, opt Options) []candidate {
+	var out []candidate
+	n := float64(len(klines))
+	pad := opt.ClusterPct / 4
+	for _, s := range pattern.Swings(klines, opt.SwingWindow) {
+		out = append(out, candidate{source: SourcePivot, low: s.Price * (1 - pad), high: s.Price * (1 + pad), weight: 0.5 + 0.5*float64(s.Index)/n, index: s.Index})
+	}
+	return out
+}
+
+// volumeNodes 把每根 K 线的成交量均匀分配到 [low, high] 的价格分箱，
+// 取成交量高于平均 1.5 倍的局部峰值分箱作为候选
+func volumeNodes(klines []storage.KLine, opt Options) []candidate {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, k := range klines {
+		lo, hi = math.Min(lo, k.Low), math.Max(hi, k.High)
+	}
+	if opt.Bins <= 0 || hi <= lo {
+		return nil
+	}
+	width := (hi - lo) / float64(opt.Bins)
+	hist := make([]float64, opt.Bins)
+	for _, k := range klines {
+		a := int((k.Low - lo) / width)
+		b := int((k.High - lo) / width)
+		if b >= opt.Bins {
+			b = opt.Bins - 1
+		}
+		if a > b {
+			a = b
+		}
+		share := k.Volume / float64(b-a+1)
+		for i := a; i <= b; i++ {
+			hist[i] += share
+		}
+	}
+	mean := 0.0
+	for _, v := range hist {
+		mean += v
+	}
+	mean /= float64(opt.Bins)
+	var out []candidate
+	for i, v := range hist {
+		if v < 1.5*mean || (i > 0 && hist[i-1] > v) || (i+1 < len(hist) && hist[i+1] >= v) {
+			continue
+		}
+		out = append(out, candidate{source: SourceVolume, low: lo + float64(i)*width, high: lo + float64(i+1)*width, weight: v / mean / 1.5, index: len(klines) - 1})
+	}
+	return out
+}
+
+// gaps 尚未回补的跳空缺口：向上缺口（当日最低价高于前一日最高价）之后没有 K 线的最低价回到缺口上沿以下，
+// 向下缺口同理
+func gaps(klines []storage.KLine) []candidate {
+	var out []candidate
+	for i := 1; i < len(klines); i++ {
+		p, k := klines[i-1], klines[i]
+		var low, high float64
+		switch {
+		case k.Low > p.High:
+			low, high = p.High, k.Low
+		case k.High < p.Low:
+			low, high = k.High, p.Low
+		default:
+			continue
+		}
+		filled := false
+		for j := i + 1; j < len(klines) && !filled; j++ {
+			// 后续 K 线完整穿过缺口视为回补
+			filled = klines[j].Low <= low && klines[j].High >= high
+		}
+		if !filled {
+			out = append(out, candidate{source: SourceGap, low: low, high: high, weight: 1, index: i})
+		}
+	}
+	return out
+}
+
+// cluster 按价格排序后把相距不超过 ClusterPct 的候选合并为区间
+func cluster(klines []storage.KLine, cands []candidate, opt Options) []Zone {
+	sort.Slice(cands, func(i, j int) bool { return cands[i].low < cands[j].low })
+	var zones []Zone
+	var group []candidate
+	flush := func() {
+		if len(group) > 0 {
+			zones = append(zones, makeZone(klines, group))
+		}
+		group = nil
+	}
+	for _, c := range cands {
+		if len(group) > 0 {
+			top := group[0].high
+			for _, g := range group {
+				top = math.Max(top, g.high)
+			}
+			// 与当前区间不相邻，或合并后区间过宽（连续价位链式合并）时另起一个区间
+			if c.low > top*(1+opt.ClusterPct) || c.high > group[0].low*(1+3*opt.ClusterPct) {
+				flush()
+			}
+		}
+		group = append(group, c)
+	}
+	flush()
+	return zones
+}
+
+func makeZone(klines []storage.KLine, group []candidate) Zone {
+	z := Zone{Low: group[0].low, High: group[0].high}
+	var wsum, psum float64
+	srcs := map[string]bool{}
+	lastIdx := 0
+	for _, c := range group {
+		z.Low, z.High = math.Min(z.Low, c.low), math.Max(z.High, c.high)
+		wsum += c.weight
+		psum += c.weight * (c.low + c.high) / 2
+		srcs[c.source] = true
+		if c.source == SourcePivot {
+			z.Touches++
+			if c.index > lastIdx {
+				lastIdx = c.index
+			}
+		}
+	}
+	z.Price = round2(psum / wsum)
+	z.Low, z.High = round2(z.Low), round2(z.High)
+	for _, s := range []string{SourcePivot, SourceVolume, SourceGap} {
+		if srcs[s] {
+			z.Sources = append(z.Sources, s)
+		}
+	}
+	if z.Touches > 0 {
+		z.LastDate = klines[lastIdx].Date
+	}
+	// 强度：触及次数（4 次满分）40 分，权重（含成交密集度与时效）30 分，每多一种来源 15 分
+	z.Strength = math.Round(math.Min(100, 40*math.Min(1, float64(z.Touches)/4)+30*math.Min(1, wsum/3)+15*float64(len(z.Sources)-1))*10) / 10
+	return z
+}
+
+// volumeIn 最高最低价与 [low, high] 有交集的 K 线成交量（按重叠比例分摊）
+func volumeIn(klines []storage.KLine, low, high float64) float64 {
+	v := 0.0
+	for _, k := range klines {
+		ol, oh := math.Max(low, k.Low), math.Min(high, k.High)
+		if oh < ol {
+			continue
+		}
+		if k.High == k.Low {
+			v += k.Volume
+			continue
+		}
+		v += k.Volume * (oh - ol) / (k.High - k.Low)
+	}
+	return v
+}
+
+func round2(v float64) float64 { return math.Round(v*100) / 100 }
+
+// NearSupport 最新收盘价位于某个支撑区间内或在其上沿上方 pct% 以内
+func (r *Result) NearSupport(pct float64) bool {
+	for _, z := range r.Supports {
+		if r.Close >= z.Low && r.Close <= z.High*(1+pct/100) {
+			return true
+		}
+	}
+	return false
+}
+
+// NearResistance 最新收盘价位于某个阻力区间内或在其下沿下方 pct% 以内
+func (r *Result) NearResistance(pct float64) bool {
+	for _, z := range r.Resistances {
+		if r.Close <= z.High && r.Close >= z.Low*(1-pct/100) {
+			return true
+		}
+	}
+	return false
+}
+
+// BreaksResistance 最后一根 K 线收盘突破前一日计算的阻力区间上沿（前一日收盘仍在上沿之下）
+func BreaksResistance(klines []storage.KLine, opt Options) bool {
+	n := len(klines)
+	if n < 2 {
+		return false
+	}
+	prev := Analyze(klines[:n-1], opt)
+	for _, z := range prev.Resistances {
+		if klines[n-2].Close <= z.High && klines[n-1].Close > z.High {
+			return true
+		}
+	}
+	return false
+}
+
+// BreaksSupport 最后一根 K 线收盘跌破前一日计算的支撑区间下沿
+func BreaksSupport(klines []storage.KLine, opt Options) bool {
+	n := len(klines)
+	if n < 2 {
+		return false
+	}
+	prev := Analyze(klines[:n-1], opt)
+	for _, z := range prev.Supports {
+		if klines[n-2].Close >= z.Low && klines[n-1].Close < z.Low {
+			return true
+		}
+	}
+	return false
+}
@@ -5,8 +5,12 @@ import (
 	"sync"
 	"time"
 
+	"go-stock-analyzer/backend/calendar"
+	"go-stock-analyzer/backend/clock"
 	"go-stock-analyzer/backend/config"
+	"go-stock-analyzer/backend/datasource"
 	"go-stock-analyzer/backend/fetcher"
+	"go-stock-analyzer/backend/jobs"
 	"go-stock-analyzer/backend/realtime"
 	"go-stock-analyzer/backend/scheduler"
 	"go-stock-analyzer/backend/storage"
@@ -17,35 +21,46 @@ func main() {
 	// 加载配置
 	config.LoadConfig("backend/config/config.yaml")
 
-	// init db
-	if err := storage.InitDB(config.Cfg.DBPath); err != nil {
-		log.Fatalf("init db failed: %v", err)
+	// 选择行情数据源
+	if err := datasource.Use(config.Cfg.DataSource); err != nil {
+		log.Fatalf("init data source failed: %v", err)
+	}
+	if err := datasource.UseHistory(config.Cfg.HistorySource); err != nil {
+		log.Fatalf("init history data source failed: %v", err)
+	}
+	log.Printf("using data source: %s (history: %s)", datasource.Current().Name(), datasource.History().Name())
+	if config.Cfg.HTTPMode != "" && config.Cfg.HTTPMode != datasource.HTTPModeLive {
+		log.Printf("upstream http mode: %s (archive: %s)", config.Cfg.HTTPMode, config.Cfg.HTTPArchiveDir)
 	}
-	// 每次启动校验板块股票列表是否有更新（首次启动会初始化）
-	log.Println("checking stock list updates from Sina...")
-	if list, err := fetcher.FetchAllStocks(); err != nil {
-		log.Printf("fetch all stocks failed: %v", err)
-	} else {
-		// compare counts; if different或更新则 upsert 到 DB
-		n, err := storage.CountStocksByBoards()
+
+	// 市场时钟
+	if config.Cfg.ClockMode == "sim" {
+		start, err := time.ParseInLocation("2006-01-02 15:04:05", config.Cfg.SimStart, clock.Shanghai)
 		if err != nil {
-			log.Printf("count stocks error: %v", err)
+			log.Fatalf("invalid sim_start: %v", err)
 		}
-		log.Printf("existing stocks in DB (boards of interest): %d; fetched: %d", n, len(list))
-		// 如果数量不一致，尝试保存（INSERT OR REPLACE 会做 upsert）
-		if len(list) == 0 {
-			log.Println("fetched stock list empty, skipping save")
-		} else if n != len(list) {
-			log.Println("stock list changed or first-run — saving fetched list...")
-			if err := storage.SaveStocks(list); err != nil {
-				log.Printf("save stocks failed: %v", err)
-			} else {
-				log.Printf("saved %d stocks", len(list))
-			}
-		} else {
-			log.Println("stock list appears unchanged; no save needed")
+		clock.Set(clock.NewSimClock(start, config.Cfg.SimSpeed))
+		log.Printf("using simulated clock from %s at %.0fx speed", config.Cfg.SimStart, config.Cfg.SimSpeed)
+	}
+
+	// 交易日历：内置休市表之外的补充休市日
+	if config.Cfg.CalendarFile != "" {
+		if err := calendar.LoadFile(config.Cfg.CalendarFile); err != nil {
+			log.Printf("load calendar file failed: %v", err)
 		}
 	}
+
+	// init db
+	if err := storage.InitDB(config.Cfg.DBPath); err != nil {
+		log.Fatalf("init db failed: %v", err)
+	}
+	// 每次启动对比证券列表，记录新上市/退市/更名/板块变动（首次启动只做初始化）
+	log.Println("checking stock list updates from data source...")
+	scheduler.RefreshStockList()
+	// 续跑上次进程退出时未完成的后台任务（如全市场回补）
+	jobs.ResumeInterrupted()
+	// 基准指数日线（上证指数、沪深300、创业板指等），后台同步
+	go fetcher.SyncBenchmarks(config.Cfg.WatchlistKlineDays)
 	// 启动时加载自选股的 K 线数据并保存
 	// load watchlist (自选股)
 	watch, _ := storage.GetWatchlist()
@@ -54,25 +69,17 @@ func main() {
 		symbols = append(symbols, w.Symbol)
 	}
 	log.Printf("symbols: %d\n", len(symbols))
-	// 为自选股抓取 300 天日 K 线并保存（后台异步执行以不阻塞 web 启动）
+	// 为自选股同步日 K 线（首次全量 300 天，之后增量，后台异步执行以不阻塞 web 启动）
 	if len(symbols) > 0 {
 		go func(syms []string) {
 			conc := config.Cfg.WorkerConcurrency
 			if conc <= 0 {
 				conc = 5
 			}
-			retries := config.Cfg.WorkerRetries
-			if retries <= 0 {
-				retries = 3
-			}
 			delayMs := config.Cfg.WorkerDelayMs
 			if delayMs <= 0 {
 				delayMs = 200
 			}
-			backoffMs := config.Cfg.WorkerBackoffMs
-			if backoffMs <= 0 {
-				backoffMs = 500
-			}
 			days := config.Cfg.WatchlistKlineDays
 			if days <= 0 {
 				days = 300
@@ -87,27 +94,17 @@ func main() {
 					defer wg.Done()
 					clientDelay := time.Duration(delayMs) * time.Millisecond
 					for sym := range jobs {
-						var lastErr error
-						for attempt := 1; attempt <= retries; attempt++ {
-							klines, err := fetcher.FetchKLine(sym, days)
-							log.Printf("worker %d: fetched kline for %s (attempt %d/%d): %d days, err=%v", id, sym, attempt, retries, len(klines), err)
-							if err == nil {
-								if err := storage.SaveKLines(sym, klines); err != nil {
-									lastErr = err
-								} else {
-									// saved successfully
-									lastErr = nil
-									break
-								}
-							} else {
-								lastErr = err
-								// backoff
-								time.Sleep(time.Duration(attempt*backoffMs) * time.Millisecond)
+						// 增量同步：库中已有数据时只补齐缺失部分，新股票自动全量回补；
+						// 重试、退避与限速由 upstream 客户端统一处理
+						n, err := fetcher.SyncKLine(sym, days)
+						if err != nil {
+							log.Printf("startup: failed to sync kline for %s: %v", sym, err)
+						} else {
+							log.Printf("worker %d: synced kline for %s: %d rows", id, sym, n)
+							if _, err := fetcher.UpdateAdjFactors(sym); err != nil {
+								log.Printf("startup: update adj factors for %s failed: %v", sym, err)
 							}
 						}
-						if lastErr != nil {
-							log.Printf("startup: failed to fetch/save kline for %s: %v", sym, lastErr)
-						}
 						time.Sleep(clientDelay)
 					}
 				}(i)
@@ -0,0 +1,364 @@
+package pattern
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"go-stock-analyzer/backend/storage"
+)
+
+// Hit 一次形态命中，Index 为形态最后一根 K 线的下标
+type Hit struct {
+	Pattern   string  `json:"pattern"`
+	Label     string  `json:"label"`
+	Index     int     `json:"index"`
+	Date      string  `json:"date"`
+	Bars      int     `json:"bars"`      // 形态包含的 K 线根数
+	Direction string  `json:"direction"` // bullish | bearish | neutral
+	Strength  float64 `json:"strength"`  // 0~100，综合形态标准程度、前期趋势与放量
+}
+
+const (
+	Bullish = "bullish"
+	Bearish = "bearish"
+	Neutral = "neutral"
+)
+
+// candle 单根 K 线的几何特征
+type candle struct {
+	o, h, l, c, v       float64
+	body, rng, up, down float64
+}
+
+func newCandle(k storage.KLine) candle {
+	x := candle{o: k.Open, h: k.High, l: k.Low, c: k.Close, v: k.Volume}
+	x.body = math.Abs(x.c - x.o)
+	x.rng = x.h - x.l
+	x.up = x.h - math.Max(x.o, x.c)
+	x.down = math.Min(x.o, x.c) - x.l
+	return x
+}
+
+func (x candle) bull() bool      { return x.c > x.o }
+func (x candle) bear() bool      { return x.c < x.o }
+func (x candle) top() float64    { return math.Max(x.o, x.c) }
+func (x candle) bottom() float64 { return math.Min(x.o, x.c) }
+func (x candle) mid() float64    { return (x.o + x.c) / 2 }
+
+// ctx 检测第 i 根时可用的上下文：已转换的 K 线、近期平均实体/振幅/成交量
+type ctx struct {
+	k                       []candle
+	avgBody, avgRng, avgVol []float64
+}
+
+// avgWindow 计算“长实体”“放量”等相对量时参考的前 N 根 K 线
+const avgWindow = 10
+
+// trendWindow 判断前期趋势所看的 K 线根数
+const trendWindow = 5
+
+func newCtx(klines []storage.KLine) *ctx {
+	n := len(klines)
+	c := &ctx{k: make([]candle, n), avgBody: make([]float64, n), avgRng: make([]float64, n), avgVol: make([]float64, n)}
+	var sb, sr, sv float64
+	for i, kl := range klines {
+		c.k[i] = newCandle(kl)
+		// 平均值只取 i 之前的 K 线，避免当前 K 线影响判定
+		if i > 0 {
+			cnt := float64(min(i, avgWindow))
+			c.avgBody[i], c.avgRng[i], c.avgVol[i] = sb/cnt, sr/cnt, sv/cnt
+		}
+		sb += c.k[i].body
+		sr += c.k[i].rng
+		sv += c.k[i].v
+		if i >= avgWindow {
+			old := c.k[i-avgWindow]
+			sb -= old.body
+			sr -= old.rng
+			sv -= old.v
+		}
+	}
+	return c
+}
+
+// trend 第 end 根（含）之前 trendWindow 根的涨跌幅，数据不足时为 0
+func (c *ctx) trend(end int) float64 {
+	start := end - trendWindow
+	if start < 0 || end >= len(c.k) || c.k[start].c <= 0 {
+		return 0
+	}
+	return c.k[end].c/c.k[start].c - 1
+}
+
+// trendScore 趋势强度 0~1（涨跌 8% 视为满分）
+func trendScore(pct float64) float64 { return clamp01(math.Abs(pct) / 0.08) }
+
+// volScore 放量程度 0~1（成交量为近期均量 2 倍视为满分）
+func (c *ctx) volScore(i int) float64 {
+	if c.avgVol[i] <= 0 {
+		return 0
+	}
+	return clamp01(c.k[i].v/c.avgVol[i] - 1)
+}
+
+func clamp01(v float64) float64 { return math.Max(0, math.Min(1, v)) }
+
+func score(parts ...float64) float64 {
+	s := 0.0
+	for _, p := range parts {
+		s += p
+	}
+	return math.Round(math.Max(0, math.Min(100, s))*10) / 10
+}
+
+// detector 在第 i 根 K 线上检测形态，未命中返回 false
+type detector struct {
+	name, label string
+	bars        int
+	detect      func(c *ctx, i int) (dir string, strength float64, ok bool)
+}
+
+var detectors = []detector{
+	{"doji", "十字星", 1, detectDoji},
+	{"doji_star", "跳空十字星", 2, detectDojiStar},
+	{"hammer", "锤子线", 1, detectHammer},
+	{"hanging_man", "上吊线", 1, detectHangingMan},
+	{"bullish_engulfing", "看涨吞没", 2, detectEngulfing(true)},
+	{"bearish_engulfing", "看跌吞没", 2, detectEngulfing(false)},
+	{"morning_star", "早晨之星", 3, detectStar(true)},
+	{"evening_star", "黄昏之星", 3, detectStar(false)},
+	{"three_white_soldiers", "红三兵", 3, detectThree(true)},
+	{"three_black_crows", "三只乌鸦", 3, detectThree(false)},
+}
+
+var byName = func() map[string]*detector {
+	m := map[string]*detector{}
+	for i := range detectors {
+		m[detectors[i].name] = &detectors[i]
+	}
+	return m
+}()
+
+// Names 返回支持的 K 线形态名
+func Names() []string {
+	out := make([]string, 0, len(detectors))
+	for _, d := range detectors {
+		out = append(out, d.name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Known 是否为支持的形态名
+func Known(name string) bool { return byName[strings.ToLower(name)] != nil }
+
+// Detect 检测全部 K 线上的指定形态（names 为空时检测全部），按 K 线顺序返回
+func Detect(klines []storage.KLine, names ...string) []Hit {
+	c := newCtx(klines)
+	ds := selectDetectors(names)
+	out := []Hit{}
+	for i := range klines {
+		out = append(out, detectAt(c, klines, i, ds)...)
+	}
+	return out
+}
+
+// DetectAt 只检测以第 i 根 K 线结束的形态
+func DetectAt(klines []storage.KLine, i int, names ...string) []Hit {
+	if i < 0 || i >= len(klines) {
+		return nil
+	}
+	return detectAt(newCtx(klines), klines, i, selectDetectors(names))
+}
+
+func selectDetectors(names []string) []*detector {
+	ds := []*detector{}
+	if len(names) == 0 {
+		for i := range detectors {
+			ds = append(ds, &detectors[i])
+		}
+		return ds
+	}
+	for _, n := range names {
+		if d := byName[strings.ToLower(strings.TrimSpace(n))]; d != nil {
+			ds = append(ds, d)
+		}
+	}
+	return ds
+}
+
+func detectAt(c *ctx, klines []storage.KLine, i int, ds []*detector) []Hit {
+	var out []Hit
+	for _, d := range ds {
+		if i < d.bars-1 || c.k[i].rng <= 0 {
+			continue
+		}
+		if dir, s, ok := d.detect(c, i); ok {
+			out = append(out, Hit{Pattern: d.name, Label: d.label, Index: i, Date: klines[i].Date, Bars: d.bars, Direction: dir, Strength: s})
+		}
+	}
+	return out
+}
+
+// isDoji 实体不超过振幅的 10%
+func isDoji(x candle) bool { return x.rng > 0 && x.body <= 0.1*x.rng }
+
+// 十字星：实体极小、上下影线都存在；振幅相对近期越大信号越强
+func detectDoji(c *ctx, i int) (string, float64, bool) {
+	x := c.k[i]
+	if !isDoji(x) || x.up == 0 || x.down == 0 {
+		return "", 0, false
+	}
+	shape := 1 - x.body/(0.1*x.rng)
+	size := 0.0
+	if c.avgRng[i] > 0 {
+		size = clamp01(x.rng / c.avgRng[i] / 1.5)
+	}
+	return Neutral, score(60*shape, 40*size), true
+}
+
+// 跳空十字星：长实体之后出现实体位于其收盘价之外的十字星，预示原趋势可能反转
+func detectDojiStar(c *ctx, i int) (string, float64, bool) {
+	p, x := c.k[i-1], c.k[i]
+	if !isDoji(x) || c.avgBody[i-1] <= 0 || p.body < 1.2*c.avgBody[i-1] {
+		return "", 0, false
+	}
+	var dir string
+	switch {
+	case p.bull() && x.bottom() >= p.c:
+		dir = Bearish
+	case p.bear() && x.top() <= p.c:
+		dir = Bullish
+	default:
+		return "", 0, false
+	}
+	long := clamp01(p.body/c.avgBody[i-1]/2 - 0.5)
+	return dir, score(40*(1-x.body/(0.1*x.rng)), 30*long, 30*trendScore(c.trend(i-1))), true
+}
+
+// hammerShape 锤子线/上吊线的共同外形：长下影（不少于实体 2 倍且超过振幅一半）、几乎没有上影
+func hammerShape(x candle) (float64, bool) {
+	if x.body > 0.35*x.rng || x.down < 2*x.body || x.down < 0.55*x.rng || x.up > 0.15*x.rng {
+		return 0, false
+	}
+	return clamp01((x.down/x.rng - 0.55) / 0.35), true
+}
+
+// 锤子线：下跌后出现，看涨
+func detectHammer(c *ctx, i int) (string, float64, bool) {
+	shape, ok := hammerShape(c.k[i])
+	t := c.trend(i - 1)
+	if !ok || t >= -0.02 {
+		return "", 0, false
+	}
+	return Bullish, score(50*shape, 30*trendScore(t), 20*c.volScore(i)), true
+}
+
+// 上吊线：上涨后出现，看跌
+func detectHangingMan(c *ctx, i int) (string, float64, bool) {
+	shape, ok := hammerShape(c.k[i])
+	t := c.trend(i - 1)
+	if !ok || t <= 0.02 {
+		return "", 0, false
+	}
+	return Bearish, score(50*shape, 30*trendScore(t), 20*c.volScore(i)), true
+}
+
+// 吞没：当日实体完全覆盖前一日反向实体，且出现在相反的前期趋势之后
+func detectEngulfing(bull bool) func(c *ctx, i int) (string, float64, bool) {
+	return func(c *ctx, i int) (string, float64, bool) {
+		p, x := c.k[i-1], c.k[i]
+		t := c.trend(i - 1)
+		var ok bool
+		if bull {
+			ok = p.bear() && x.bull() && x.o <= p.c && x.c >= p.o && t < 0
+		} else {
+			ok = p.bull() && x.bear() && x.o >= p.c && x.c <= p.o && t > 0
+		}
+		if !ok || x.body <= p.body {
+			return "", 0, false
+		}
+		dir := Bearish
+		if bull {
+			dir = Bullish
+		}
+		return dir, score(50*clamp01(x.body/p.body-1), 30*trendScore(t), 20*c.volScore(i)), true
+	}
+}
+
+// 早晨之星/黄昏之星：长实体 + 实体位于其外的小实体星线 + 收盘深入第一根实体一半以上的反向实体
+func detectStar(bull bool) func(c *ctx, i int) (string, float64, bool) {
+	return func(c *ctx, i int) (string, float64, bool) {
+		a, s, b := c.k[i-2], c.k[i-1], c.k[i]
+		if c.avgBody[i-2] <= 0 || a.body < c.avgBody[i-2] || s.body > 0.5*a.body {
+			return "", 0, false
+		}
+		var ok bool
+		var pen float64
+		if bull {
+			ok = a.bear() && b.bull() && s.mid() < a.c && b.c > a.mid()
+			pen = (b.c - a.mid()) / (a.o - a.mid())
+		} else {
+			ok = a.bull() && b.bear() && s.mid() > a.c && b.c < a.mid()
+			pen = (a.mid() - b.c) / (a.mid() - a.o)
+		}
+		if !ok {
+			return "", 0, false
+		}
+		dir := Bearish
+		if bull {
+			dir = Bullish
+		}
+		small := 1 - s.body/(0.5*a.body)
+		return dir, score(40*clamp01(pen), 20*small, 20*trendScore(c.trend(i-2)), 20*c.volScore(i)), true
+	}
+}
+
+// 红三兵/三只乌鸦：连续三根同向实体，收盘逐日推进，开盘位于前一根实体内，影线短
+func detectThree(bull bool) func(c *ctx, i int) (string, float64, bool) {
+	return func(c *ctx, i int) (string, float64, bool) {
+		if c.avgBody[i-2] <= 0 {
+			return "", 0, false
+		}
+		shadow := 0.0
+		for j := i - 2; j <= i; j++ {
+			x := c.k[j]
+			if (bull && !x.bull()) || (!bull && !x.bear()) || x.body < 0.5*c.avgBody[i-2] {
+				return "", 0, false
+			}
+			if j > i-2 {
+				p := c.k[j-1]
+				if x.o < p.bottom() || x.o > p.top() {
+					return "", 0, false
+				}
+				if (bull && x.c <= p.c) || (!bull && x.c >= p.c) {
+					return "", 0, false
+				}
+			}
+			end := x.up
+			if !bull {
+				end = x.down
+			}
+			if end > 0.5*x.body {
+				return "", 0, false
+			}
+			shadow += end / x.body
+		}
+		dir := Bearish
+		if bull {
+			dir = Bullish
+		}
+		gain := math.Abs(c.k[i].c/c.k[i-2].o - 1)
+		return dir, score(40*clamp01(gain/0.06), 30*(1-shadow/1.5), 30*c.volScore(i)), true
+	}
+}
+
+// Last 最后一根 K 线上指定形态的命中，未命中返回 nil
+func Last(klines []storage.KLine, name string) *Hit {
+	hits := DetectAt(klines, len(klines)-1, name)
+	if len(hits) == 0 {
+		return nil
+	}
+	return &hits[0]
+}
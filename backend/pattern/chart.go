@@ -0,0 +1,504 @@
+package pattern
+
+import (
+	"math"
+	"sort"
+
+	"go-stock-analyzer/backend/storage"
+)
+
+// Swing 波段高/低点（左右各 Window 根 K 线内的最高价/最低价）
+type Swing struct {
+	Index int     `json:"index"`
+	Date  string  `json:"date"`
+	Price float64 `json:"price"`
+	High  bool    `json:"high"`
+}
+
+// Point 图表上的一个点
+type Point struct {
+	Index int     `json:"index"`
+	Date  string  `json:"date"`
+	Price float64 `json:"price"`
+}
+
+// Line 叠加到图表的线段（颈线、趋势线、区间上下沿等）
+type Line struct {
+	Name string `json:"name"`
+	From Point  `json:"from"`
+	To   Point  `json:"to"`
+}
+
+// ChartPattern 多根 K 线构成的图表形态
+type ChartPattern struct {
+	Type      string             `json:"type"`
+	Label     string             `json:"label"`
+	Direction string             `json:"direction"`
+	Start     int                `json:"start"`
+	End       int                `json:"end"` // 形态最后一个关键点；确认突破时为突破 K 线
+	StartDate string             `json:"start_date"`
+	EndDate   string             `json:"end_date"`
+	Points    []Swing            `json:"points"` // 构成形态的关键波段点
+	Levels    map[string]float64 `json:"levels"` // neckline / breakout / target / support / resistance ...
+	Lines     []Line             `json:"lines"`
+	// Confirmed 收盘价已突破关键价位；BreakoutIndex 为突破 K 线下标（未突破为 -1）
+	Confirmed       bool    `json:"confirmed"`
+	BreakoutIndex   int     `json:"breakout_index"`
+	BreakoutDate    string  `json:"breakout_date,omitempty"`
+	VolumeConfirmed bool    `json:"volume_confirmed"` // 突破当日成交量不低于近期均量的 VolumeRatio 倍
+	Strength        float64 `json:"strength"`
+}
+
+// ChartOptions 图表形态识别参数
+type ChartOptions struct {
+	SwingWindow int     // 波段点左右确认的 K 线根数
+	Tolerance   float64 // 双顶/双底、头肩的价位容差（比例）
+	MinDepth    float64 // 双顶/双底中间回撤的最小幅度（比例）
+	MinBars     int     // 形态最短跨度（K 线根数）
+	MaxBars     int     // 形态最长跨度
+	RangeDays   int     // 区间突破的 N 日
+	RangeWidth  float64 // 区间突破要求 N 日区间振幅不超过该比例
+	FlagPole    float64 // 旗形旗杆的最小涨跌幅
+	VolumeRatio float64 // 放量确认倍数（相对前 RangeDays 日均量）
+}
+
+// DefaultChartOptions 日线默认参数
+func DefaultChartOptions() ChartOptions {
+	return ChartOptions{
+		SwingWindow: 5,
+		Tolerance:   0.03,
+		MinDepth:    0.05,
+		MinBars:     10,
+		MaxBars:     120,
+		RangeDays:   20,
+		RangeWidth:  0.15,
+		FlagPole:    0.10,
+		VolumeRatio: 1.5,
+	}
+}
+
+// chartLabels 图表形态中文名
+var chartLabels = map[string]string{
+	"double_top":                 "双顶",
+	"double_bottom":              "双底",
+	"head_and_shoulders":         "头肩顶",
+	"inverse_head_and_shoulders": "头肩底",
+	"ascending_triangle":         "上升三角形",
+	"descending_triangle":        "下降三角形",
+	"symmetrical_triangle":       "对称三角形",
+	"bull_flag":                  "上升旗形",
+	"bear_flag":                  "下降旗形",
+	"range_breakout":             "区间向上突破",
+	"range_breakdown":            "区间向下跌破",
+}
+
+// ChartTypes 返回支持的图表形态
+func ChartTypes() []string {
+	out := make([]string, 0, len(chartLabels))
+	for t := range chartLabels {
+		out = append(out, t)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// IsChartType 是否为支持的图表形态
+func IsChartType(t string) bool { _, ok := chartLabels[t]; return ok }
+
+// Swings 找出波段高低点，并保证高低点交替（连续同类点只保留更极端的一个）
+func Swings(klines []storage.KLine, window int) []Swing {
+	if window <= 0 {
+		window = 1
+	}
+	out := []Swing{}
+	add := func(s Swing) {
+		if n := len(out); n > 0 && out[n-1].High == s.High {
+			if (s.High && s.Price > out[n-1].Price) || (!s.High && s.Price < out[n-1].Price) {
+				out[n-1] = s
+			}
+			return
+		}
+		out = append(out, s)
+	}
+	for i := window; i < len(klines)-window; i++ {
+		isHigh, isLow := true, true
+		for j := i - window; j <= i+window; j++ {
+			if j == i {
+				continue
+			}
+			// 相等价位时取最早的一根
+			if klines[j].High > klines[i].High || (j < i && klines[j].High == klines[i].High) {
+				isHigh = false
+			}
+			if klines[j].Low < klines[i].Low || (j < i && klines[j].Low == klines[i].Low) {
+				isLow = false
+			}
+		}
+		hi := Swing{Index: i, Date: klines[i].Date, Price: klines[i].High, High: true}
+		lo := Swing{Index: i, Date: klines[i].Date, Price: klines[i].Low}
+		switch {
+		case isHigh && isLow:
+			// 长振幅 K 线同时是高点和低点：先放与上一个点交替的那个
+			if len(out) > 0 && out[len(out)-1].High {
+				add(lo)
+				add(hi)
+			} else {
+				add(hi)
+				add(lo)
+			}
+		case isHigh:
+			add(hi)
+		case isLow:
+			add(lo)
+		}
+	}
+	return out
+}
+
+// chartCtx 图表形态识别的公共数据
+type chartCtx struct {
+	k      []storage.KLine
+	opt    ChartOptions
+	avgVol []float64 // 前 RangeDays 根的平均成交量（不含当根）
+}
+
+func newChartCtx(klines []storage.KLine, opt ChartOptions) *chartCtx {
+	c := &chartCtx{k: klines, opt: opt, avgVol: make([]float64, len(klines))}
+	sum := 0.0
+	for i, k := range klines {
+		if i > 0 {
+			c.avgVol[i] = sum / float64(min(i, opt.RangeDays))
+		}
+		sum += k.Volume
+		if i >= opt.RangeDays {
+			sum -= klines[i-opt.RangeDays].Volume
+		}
+	}
+	return c
+}
+
+func (c *chartCtx) point(i int, price float64) Point {
+	return Point{Index: i, Date: c.k[i].Date, Price: price}
+}
+
+func (c *chartCtx) volumeOK(i int) bool {
+	return c.avgVol[i] > 0 && c.k[i].Volume >= c.opt.VolumeRatio*c.avgVol[i]
+}
+
+// breakout 从 from 开始找第一根收盘价突破 level(i) 的 K 线（up 为向上），
+// 收盘价先触及 invalid(i) 或超过 until 时视为没有突破；返回 -1 表示未突破
+func (c *chartCtx) breakout(from, until int, up bool, level, invalid func(i int) float64) int {
+	for i := from; i < len(c.k) && i <= until; i++ {
+		cl := c.k[i].Close
+		if invalid != nil {
+			if inv := invalid(i); (up && cl < inv) || (!up && cl > inv) {
+				return -1
+			}
+		}
+		if (up && cl > level(i)) || (!up && cl < level(i)) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (c *chartCtx) newPattern(typ, dir string, pts []Swing, end int) ChartPattern {
+	return ChartPattern{
+		Type: typ, Label: chartLabels[typ], Direction: dir,
+		Start: pts[0].Index, End: end, StartDate: pts[0].Date, EndDate: c.k[end].Date,
+		Points: pts, Levels: map[string]float64{}, BreakoutIndex: -1,
+	}
+}
+
+// confirm 记录突破信息
+func (c *chartCtx) confirm(p *ChartPattern, b int) {
+	if b < 0 {
+		return
+	}
+	p.Confirmed, p.BreakoutIndex, p.BreakoutDate = true, b, c.k[b].Date
+	p.End, p.EndDate = b, c.k[b].Date
+	p.VolumeConfirmed = c.volumeOK(b)
+}
+
+func constant(v float64) func(int) float64 { return func(int) float64 { return v } }
+
+// lineThrough 过两个波段点的直线
+func lineThrough(a, b Swing) func(i int) float64 {
+	slope := (b.Price - a.Price) / float64(b.Index-a.Index)
+	return func(i int) float64 { return a.Price + slope*float64(i-a.Index) }
+}
+
+func relDiff(a, b float64) float64 { return math.Abs(a-b) / math.Max(math.Abs(a), math.Abs(b)) }
+
+// DetectChart 在 K 线上识别全部图表形态，按结束位置排序
+func DetectChart(klines []storage.KLine, opt ChartOptions) []ChartPattern {
+	c := newChartCtx(klines, opt)
+	sw := Swings(klines, opt.SwingWindow)
+	out := []ChartPattern{}
+	out = append(out, c.doubles(sw)...)
+	out = append(out, c.headShoulders(sw)...)
+	out = append(out, c.triangles(sw)...)
+	out = append(out, c.flags(sw)...)
+	out = append(out, c.ranges()...)
+	sort.SliceStable(out, func(i, j int) bool { return out[i].End < out[j].End })
+	return out
+}
+
+// doubles 双顶（高-低-高）与双底（低-高-低）：两端价位相差不超过 Tolerance，中间回撤不少于 MinDepth，
+// 收盘跌破/突破颈线（中间点价位）即确认，目标位为颈线再延伸一个形态高度
+func (c *chartCtx) doubles(sw []Swing) []ChartPattern {
+	var out []ChartPattern
+	for k := 0; k+2 < len(sw); k++ {
+		a, m, b := sw[k], sw[k+1], sw[k+2]
+		span := b.Index - a.Index
+		if span < c.opt.MinBars || span > c.opt.MaxBars {
+			continue
+		}
+		diff := relDiff(a.Price, b.Price)
+		depth := math.Abs(m.Price-a.Price) / a.Price
+		if diff > c.opt.Tolerance || depth < c.opt.MinDepth {
+			continue
+		}
+		typ, dir, up := "double_top", Bearish, false
+		if !a.High {
+			typ, dir, up = "double_bottom", Bullish, true
+		}
+		extreme := math.Max(a.Price, b.Price)
+		if !a.High {
+			extreme = math.Min(a.Price, b.Price)
+		}
+		p := c.newPattern(typ, dir, []Swing{a, m, b}, b.Index)
+		p.Levels["neckline"] = m.Price
+		p.Levels["target"] = 2*m.Price - extreme
+		p.Levels["invalidation"] = extreme
+		c.confirm(&p, c.breakout(b.Index+1, b.Index+span, up, constant(m.Price), constant(extreme)))
+		p.Lines = []Line{
+			{Name: "neckline", From: c.point(a.Index, m.Price), To: c.point(p.End, m.Price)},
+			{Name: "tops", From: c.point(a.Index, a.Price), To: c.point(b.Index, b.Price)},
+		}
+		p.Strength = score(40*(1-diff/c.opt.Tolerance), 30*clamp01(depth/(3*c.opt.MinDepth)), 30*boolScore(p.VolumeConfirmed))
+		out = append(out, p)
+	}
+	return out
+}
+
+// headShoulders 头肩顶（高-低-高-低-高，中间最高，两肩相近）与头肩底，颈线为两个回撤点的连线
+func (c *chartCtx) headShoulders(sw []Swing) []ChartPattern {
+	var out []ChartPattern
+	for k := 0; k+4 < len(sw); k++ {
+		l, n1, h, n2, r := sw[k], sw[k+1], sw[k+2], sw[k+3], sw[k+4]
+		span := r.Index - l.Index
+		if span < c.opt.MinBars || span > c.opt.MaxBars {
+			continue
+		}
+		top := l.High
+		var headOK bool
+		if top {
+			headOK = h.Price > l.Price*(1+c.opt.Tolerance) && h.Price > r.Price*(1+c.opt.Tolerance)
+		} else {
+			headOK = h.Price < l.Price*(1-c.opt.Tolerance) && h.Price < r.Price*(1-c.opt.Tolerance)
+		}
+		shoulders := relDiff(l.Price, r.Price)
+		if !headOK || shoulders > 2*c.opt.Tolerance {
+			continue
+		}
+		typ, dir := "head_and_shoulders", Bearish
+		if !top {
+			typ, dir = "inverse_head_and_shoulders", Bullish
+		}
+		neck := lineThrough(n1, n2)
+		p := c.newPattern(typ, dir, []Swing{l, n1, h, n2, r}, r.Index)
+		height := math.Abs(h.Price - neck(h.Index))
+		c.confirm(&p, c.breakout(r.Index+1, r.Index+span, !top, neck, constant(h.Price)))
+		p.Levels["head"] = h.Price
+		p.Levels["neckline"] = neck(p.End)
+		if top {
+			p.Levels["target"] = neck(p.End) - height
+		} else {
+			p.Levels["target"] = neck(p.End) + height
+		}
+		p.Lines = []Line{{Name: "neckline", From: c.point(l.Index, neck(l.Index)), To: c.point(p.End, neck(p.End))}}
+		prom := math.Abs(h.Price-math.Max(l.Price, r.Price)) / h.Price
+		if !top {
+			prom = math.Abs(math.Min(l.Price, r.Price)-h.Price) / h.Price
+		}
+		p.Strength = score(30*(1-shoulders/(2*c.opt.Tolerance)), 30*clamp01(prom/0.1), 20*boolScore(p.Confirmed), 20*boolScore(p.VolumeConfirmed))
+		out = append(out, p)
+	}
+	return out
+}
+
+// triangles 由连续两个高点、两个低点连成的收敛形态：
+// 上沿走平下沿抬高为上升三角形，上沿下降下沿走平为下降三角形，上沿下降下沿抬高为对称三角形
+func (c *chartCtx) triangles(sw []Swing) []ChartPattern {
+	var out []ChartPattern
+	for k := 0; k+3 < len(sw); k++ {
+		pts := sw[k : k+4]
+		var h1, h2, l1, l2 Swing
+		if pts[0].High {
+			h1, l1, h2, l2 = pts[0], pts[1], pts[2], pts[3]
+		} else {
+			l1, h1, l2, h2 = pts[0], pts[1], pts[2], pts[3]
+		}
+		last := pts[3].Index
+		span := last - pts[0].Index
+		if span < c.opt.MinBars || span > c.opt.MaxBars {
+			continue
+		}
+		hd := (h2.Price - h1.Price) / h1.Price
+		ld := (l2.Price - l1.Price) / l1.Price
+		flat := c.opt.Tolerance / 2
+		var typ, dir string
+		switch {
+		case math.Abs(hd) <= flat && ld > flat:
+			typ, dir = "ascending_triangle", Bullish
+		case hd < -flat && math.Abs(ld) <= flat:
+			typ, dir = "descending_triangle", Bearish
+		case hd < -flat && ld > flat:
+			typ, dir = "symmetrical_triangle", Neutral
+		default:
+			continue
+		}
+		upper, lower := lineThrough(h1, h2), lineThrough(l1, l2)
+		if math.Abs(hd) <= flat {
+			upper = constant(math.Max(h1.Price, h2.Price))
+		}
+		if math.Abs(ld) <= flat {
+			lower = constant(math.Min(l1.Price, l2.Price))
+		}
+		height := upper(pts[0].Index) - lower(pts[0].Index)
+		if height <= 0 {
+			continue
+		}
+		p := c.newPattern(typ, dir, append([]Swing(nil), pts...), last)
+		// 向上或向下突破，取先发生的一个；对称三角形的方向由突破方向决定
+		upB := c.breakout(last+1, last+span, true, upper, nil)
+		downB := c.breakout(last+1, last+span, false, lower, nil)
+		b := upB
+		if downB >= 0 && (upB < 0 || downB < upB) {
+			b = downB
+		}
+		c.confirm(&p, b)
+		if p.Confirmed {
+			p.Direction = Bullish
+			if b == downB {
+				p.Direction = Bearish
+			}
+		}
+		p.Levels["resistance"] = upper(p.End)
+		p.Levels["support"] = lower(p.End)
+		if p.Direction == Bearish {
+			p.Levels["target"] = lower(p.End) - height
+		} else {
+			p.Levels["target"] = upper(p.End) + height
+		}
+		p.Lines = []Line{
+			{Name: "resistance", From: c.point(pts[0].Index, upper(pts[0].Index)), To: c.point(p.End, upper(p.End))},
+			{Name: "support", From: c.point(pts[0].Index, lower(pts[0].Index)), To: c.point(p.End, lower(p.End))},
+		}
+		p.Strength = score(40*clamp01(1-(upper(last)-lower(last))/height), 30*boolScore(p.Confirmed), 30*boolScore(p.VolumeConfirmed))
+		out = append(out, p)
+		k += 2 // 相邻窗口会重复识别同一个三角形
+	}
+	return out
+}
+
+// flags 旗形：旗杆（低点到高点 ≤15 根 K 线内涨幅不少于 FlagPole）之后回撤不超过旗杆一半的整理，
+// 收盘重新越过旗杆顶点即确认；下降旗形相反
+func (c *chartCtx) flags(sw []Swing) []ChartPattern {
+	var out []ChartPattern
+	for k := 0; k+1 < len(sw); k++ {
+		base, tip := sw[k], sw[k+1]
+		if tip.Index-base.Index > 15 {
+			continue
+		}
+		pole := (tip.Price - base.Price) / base.Price
+		up := tip.High
+		if (up && pole < c.opt.FlagPole) || (!up && -pole < c.opt.FlagPole) {
+			continue
+		}
+		half := tip.Price - (tip.Price-base.Price)/2
+		// 整理至少 3 根、最多 20 根；3 根以内就创新高的视为旗杆的延续
+		b := c.breakout(tip.Index+1, tip.Index+20, up, constant(tip.Price), constant(half))
+		if b < tip.Index+3 {
+			continue
+		}
+		typ, dir := "bull_flag", Bullish
+		if !up {
+			typ, dir = "bear_flag", Bearish
+		}
+		p := c.newPattern(typ, dir, []Swing{base, tip}, b)
+		c.confirm(&p, b)
+		hi, lo := c.k[tip.Index+1].High, c.k[tip.Index+1].Low
+		for i := tip.Index + 1; i < b; i++ {
+			hi, lo = math.Max(hi, c.k[i].High), math.Min(lo, c.k[i].Low)
+		}
+		p.Levels["pole_base"] = base.Price
+		p.Levels["breakout"] = tip.Price
+		p.Levels["flag_high"], p.Levels["flag_low"] = hi, lo
+		p.Levels["target"] = tip.Price + (tip.Price - base.Price)
+		p.Lines = []Line{
+			{Name: "pole", From: c.point(base.Index, base.Price), To: c.point(tip.Index, tip.Price)},
+			{Name: "flag_high", From: c.point(tip.Index, hi), To: c.point(b, hi)},
+			{Name: "flag_low", From: c.point(tip.Index, lo), To: c.point(b, lo)},
+		}
+		retrace := math.Abs(tip.Price-lo) / math.Abs(tip.Price-base.Price)
+		if !up {
+			retrace = math.Abs(hi-tip.Price) / math.Abs(tip.Price-base.Price)
+		}
+		p.Strength = score(30*clamp01(math.Abs(pole)/(2*c.opt.FlagPole)), 30*(1-clamp01(retrace/0.5)), 40*boolScore(p.VolumeConfirmed))
+		out = append(out, p)
+	}
+	return out
+}
+
+// ranges N 日区间突破：前 RangeDays 日振幅不超过 RangeWidth，收盘突破区间上沿（或跌破下沿）且放量；
+// 只记录脱离区间的第一根
+func (c *chartCtx) ranges() []ChartPattern {
+	var out []ChartPattern
+	n := c.opt.RangeDays
+	for i := n; i < len(c.k); i++ {
+		hi, lo := c.k[i-n].High, c.k[i-n].Low
+		for j := i - n; j < i; j++ {
+			hi, lo = math.Max(hi, c.k[j].High), math.Min(lo, c.k[j].Low)
+		}
+		if lo <= 0 || (hi-lo)/lo > c.opt.RangeWidth || !c.volumeOK(i) {
+			continue
+		}
+		cl := c.k[i].Close
+		var typ, dir string
+		switch {
+		case cl > hi:
+			typ, dir = "range_breakout", Bullish
+		case cl < lo:
+			typ, dir = "range_breakdown", Bearish
+		default:
+			continue
+		}
+		start := Swing{Index: i - n, Date: c.k[i-n].Date, Price: hi, High: true}
+		p := c.newPattern(typ, dir, []Swing{start}, i)
+		p.Points = []Swing{}
+		c.confirm(&p, i)
+		p.Levels["range_high"], p.Levels["range_low"] = hi, lo
+		p.Lines = []Line{
+			{Name: "range_high", From: c.point(i-n, hi), To: c.point(i, hi)},
+			{Name: "range_low", From: c.point(i-n, lo), To: c.point(i, lo)},
+		}
+		beyond := (cl - hi) / hi
+		if dir == Bearish {
+			beyond = (lo - cl) / lo
+		}
+		width := (hi - lo) / lo
+		p.Strength = score(30*clamp01(beyond/0.03), 30*(1-width/c.opt.RangeWidth), 40*clamp01(c.k[i].Volume/c.avgVol[i]/(2*c.opt.VolumeRatio)))
+		out = append(out, p)
+		i += n / 2 // 同一次突破后的几天不重复记录
+	}
+	return out
+}
+
+func boolScore(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
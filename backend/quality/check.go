@@ -0,0 +1,54 @@
+package quality
+
+import (
+	"log"
+
+	"go-stock-analyzer/backend/storage"
+)
+
+// CheckSymbol 校验库中某只证券的全部日 K 线并把结果写入 kline_issues 表
+func CheckSymbol(symbol string) ([]storage.KLineIssue, error) {
+	klines, err := storage.LoadAllKLines(symbol)
+	if err != nil {
+		return nil, err
+	}
+	info, err := storage.GetStock(symbol)
+	if err != nil {
+		return nil, err
+	}
+	factors, err := storage.LoadAdjFactors(symbol)
+	if err != nil {
+		return nil, err
+	}
+	opt := Options{
+		LimitFor:     func(date string) float64 { return PriceLimit(info, date) },
+		ExRightDates: map[string]bool{},
+	}
+	if info != nil {
+		opt.ListDate = info.ListDate
+	}
+	for i := 1; i < len(factors); i++ {
+		if factors[i].Factor != factors[i-1].Factor {
+			opt.ExRightDates[factors[i].Date] = true
+		}
+	}
+	issues := Validate(symbol, klines, opt)
+	if err := storage.ReplaceKLineIssues(symbol, issues); err != nil {
+		return nil, err
+	}
+	return issues, nil
+}
+
+// CheckSymbols 批量校验，返回每只证券的问题数；单只失败只记录日志
+func CheckSymbols(symbols []string) map[string]int {
+	out := map[string]int{}
+	for _, sym := range symbols {
+		issues, err := CheckSymbol(sym)
+		if err != nil {
+			log.Printf("data quality check %s error: %v", sym, err)
+			continue
+		}
+		out[sym] = len(issues)
+	}
+	return out
+}
@@ -0,0 +1,187 @@
+package quality
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"go-stock-analyzer/backend/calendar"
+	"go-stock-analyzer/backend/storage"
+)
+
+// 问题类型
+const (
+	IssueOHLC          = "ohlc_inconsistent"    // 最高/最低价与开收盘矛盾
+	IssueNonPositive   = "non_positive_price"   // 价格为 0 或负数
+	IssueDuplicate     = "duplicate_date"       // 日期重复或乱序
+	IssueNonTradingDay = "non_trading_day"      // 休市日出现 K 线
+	IssueMissingDay    = "missing_day"          // 相对交易日历缺失的交易日（连续缺失合并为一条）
+	IssueExtremeJump   = "extreme_jump"         // 涨跌幅超过所在板块的涨跌停限制
+	IssueNoCalendar    = "calendar_unavailable" // 休市表未覆盖该年份，缺失/休市日检查未执行
+)
+
+// 严重程度
+const (
+	SeverityError = "error"
+	SeverityWarn  = "warn"
+)
+
+// limitTolerance 涨跌停价按分位四舍五入，校验时放宽一个百分点
+const limitTolerance = 0.01
+
+// newListingBars 新股上市初期（注册制前 5 个交易日）不设涨跌幅限制
+const newListingBars = 5
+
+// Options 校验参数
+type Options struct {
+	// 涨跌幅限制（如 0.1），<=0 时不做跳变检查
+	Limit float64
+	// LimitFor 按日期返回涨跌幅限制，优先于 Limit（如创业板 2020-08-24 起由 10% 改为 20%）
+	LimitFor func(date string) float64
+	// 上市日期，上市初期的 K 线不做跳变检查
+	ListDate string
+	// 除权除息日（复权因子变化的日期），不复权数据在这些日期跳空属正常
+	ExRightDates map[string]bool
+}
+
+// PriceLimit 返回证券在 date 的涨跌幅限制；指数返回 0（不检查）
+func PriceLimit(s *storage.StockInfo, date string) float64 {
+	if s == nil {
+		return 0.10
+	}
+	switch {
+	case s.SecType == storage.SecTypeIndex:
+		return 0
+	case s.Board == "北交所":
+		return 0.30
+	case s.Board == "科创板":
+		return 0.20
+	case s.Board == "创业板":
+		if date < "2020-08-24" {
+			return 0.10
+		}
+		return 0.20
+	case s.IsST:
+		return 0.05
+	default:
+		return 0.10
+	}
+}
+
+func issue(symbol, date, typ, sev, detail string) storage.KLineIssue {
+	return storage.KLineIssue{Symbol: symbol, Date: date, IssueType: typ, Severity: sev, Detail: detail}
+}
+
+// Validate 校验按日期升序排列的日 K 线，返回发现的问题
+func Validate(symbol string, klines []storage.KLine, opt Options) []storage.KLineIssue {
+	issues := []storage.KLineIssue{}
+	seen := map[string]bool{}
+	prevDate := ""
+	var prev *storage.KLine
+	listed := 0
+	for i := range klines {
+		k := &klines[i]
+		date := k.Date
+		if len(date) > 10 {
+			date = date[:10]
+		}
+		if seen[date] {
+			issues = append(issues, issue(symbol, date, IssueDuplicate, SeverityError, "duplicate bar for date"))
+			continue
+		}
+		seen[date] = true
+		if date < prevDate {
+			issues = append(issues, issue(symbol, date, IssueDuplicate, SeverityError, fmt.Sprintf("out of order after %s", prevDate)))
+		}
+		prevDate = date
+
+		if t, err := time.Parse("2006-01-02", date); err == nil && calendar.Covers(t.Year()) && !calendar.IsTradingDay(t) {
+			issues = append(issues, issue(symbol, date, IssueNonTradingDay, SeverityError, "bar on a non-trading day"))
+		}
+		if k.Open <= 0 || k.High <= 0 || k.Low <= 0 || k.Close <= 0 {
+			issues = append(issues, issue(symbol, date, IssueNonPositive, SeverityError,
+				fmt.Sprintf("open=%g high=%g low=%g close=%g", k.Open, k.High, k.Low, k.Close)))
+			// 价格无效时不参与后续的 OHLC 与跳变判断
+			continue
+		}
+		if k.High < math.Max(k.Open, k.Close) || k.Low > math.Min(k.Open, k.Close) || k.High < k.Low {
+			issues = append(issues, issue(symbol, date, IssueOHLC, SeverityError,
+				fmt.Sprintf("open=%g high=%g low=%g close=%g", k.Open, k.High, k.Low, k.Close)))
+		}
+		if opt.ListDate == "" || date >= opt.ListDate {
+			listed++
+		}
+		limit := opt.Limit
+		if opt.LimitFor != nil {
+			limit = opt.LimitFor(date)
+		}
+		if prev != nil && limit > 0 && !opt.ExRightDates[date] && !(opt.ListDate != "" && listed <= newListingBars) {
+			pct := k.Close/prev.Close - 1
+			if math.Abs(pct) > limit+limitTolerance {
+				sev := SeverityWarn
+				if math.Abs(pct) > 2*limit {
+					sev = SeverityError
+				}
+				issues = append(issues, issue(symbol, date, IssueExtremeJump, sev,
+					fmt.Sprintf("close %g -> %g (%+.2f%%), limit %.0f%%", prev.Close, k.Close, pct*100, limit*100)))
+			}
+		}
+		prev = k
+	}
+	return append(issues, missingDays(symbol, klines, seen)...)
+}
+
+// missingDays 对比交易日历找出首末日期之间缺失的交易日，连续缺失合并为一条（日期为缺失区间首日）。
+// 只检查休市表覆盖的年份，未覆盖的年份各报一条 calendar_unavailable；停牌也会表现为缺失，因此级别为 warn。
+func missingDays(symbol string, klines []storage.KLine, seen map[string]bool) []storage.KLineIssue {
+	issues := []storage.KLineIssue{}
+	if len(klines) < 2 {
+		return issues
+	}
+	first, err1 := time.Parse("2006-01-02", firstDate(klines[0].Date))
+	last, err2 := time.Parse("2006-01-02", firstDate(klines[len(klines)-1].Date))
+	if err1 != nil || err2 != nil {
+		return issues
+	}
+	var run []string
+	flush := func() {
+		if len(run) == 0 {
+			return
+		}
+		detail := fmt.Sprintf("%d trading day(s) missing", len(run))
+		if len(run) > 1 {
+			detail += fmt.Sprintf(": %s..%s", run[0], run[len(run)-1])
+		}
+		issues = append(issues, issue(symbol, run[0], IssueMissingDay, SeverityWarn, detail))
+		run = nil
+	}
+	for _, d := range calendar.TradingDays(first, last) {
+		t, _ := time.Parse("2006-01-02", d)
+		if !calendar.Covers(t.Year()) || seen[d] {
+			flush()
+			continue
+		}
+		run = append(run, d)
+	}
+	flush()
+	// 首末日期之间休市表未覆盖的年份各报一条（日期为该年在区间内的首日）
+	for y := first.Year(); y <= last.Year(); y++ {
+		if calendar.Covers(y) {
+			continue
+		}
+		date := fmt.Sprintf("%d-01-01", y)
+		if y == first.Year() {
+			date = first.Format("2006-01-02")
+		}
+		issues = append(issues, issue(symbol, date, IssueNoCalendar, SeverityWarn,
+			fmt.Sprintf("trading calendar not available for %d, missing/non-trading day checks skipped", y)))
+	}
+	return issues
+}
+
+func firstDate(d string) string {
+	if len(d) > 10 {
+		return d[:10]
+	}
+	return d
+}
@@ -4,30 +4,34 @@ import (
 	"log"
 	"time"
 
+	"go-stock-analyzer/backend/calendar"
+	"go-stock-analyzer/backend/clock"
 	"go-stock-analyzer/backend/config"
 	"go-stock-analyzer/backend/fetcher"
+	"go-stock-analyzer/backend/quality"
 	"go-stock-analyzer/backend/storage"
 	"go-stock-analyzer/backend/strategy"
 )
 
-// StartDailyTask 启动每日定时任务协程
+// StartDailyTask 启动每日定时任务协程（只在交易日运行）
 func StartDailyTask() {
 	go func() {
 		for {
-			now := time.Now()
+			now := clock.Now()
 			next := time.Date(now.Year(), now.Month(), now.Day(), config.Cfg.UpdateHour, config.Cfg.UpdateMinute, 0, 0, now.Location())
-			if now.After(next) {
-				next = next.Add(24 * time.Hour)
+			if now.After(next) || !calendar.IsTradingDay(next) {
+				next = calendar.NextTradingDay(next)
 			}
-			sleep := time.Until(next)
 			log.Printf("Scheduler sleeping until %v\n", next)
-			time.Sleep(sleep)
+			clock.Sleep(next.Sub(now))
 			runAnalysis()
 		}
 	}()
 }
 // 执行每日分析任务
 func runAnalysis() {
+	RefreshStockList()
+	fetcher.SyncBenchmarks(config.Cfg.KLineDays)
 	log.Println("Daily analysis start: reading watchlist")
 	watch, err := storage.GetWatchlist()
 	if err != nil {
@@ -46,17 +50,58 @@ func runAnalysis() {
 	log.Printf("Analyzing %d watched stocks\n", len(symbols))
 
 	for _, sym := range symbols {
-		klines, err := fetcher.FetchKLine(sym, config.Cfg.KLineDays)
-		if err != nil {
-			log.Printf("fetch kline %s error: %v", sym, err)
+		// 增量同步：只抓取库中最新日期之后的数据
+		if _, err := fetcher.SyncKLine(sym, config.Cfg.KLineDays); err != nil {
+			log.Printf("sync kline %s error: %v", sym, err)
 			continue
 		}
-		
-		if err := storage.SaveKLines(sym, klines); err != nil {
-			log.Printf("save kline %s error: %v", sym, err)
+		if _, err := fetcher.UpdateAdjFactors(sym); err != nil {
+			log.Printf("update adj factors %s error: %v", sym, err)
 		}
 	}
+	// 日 K 线数据质量校验（结果见 /api/data_quality）
+	counts := quality.CheckSymbols(append(symbols, fetcher.BenchmarkSymbols()...))
+	log.Printf("data quality checked %d symbols", len(counts))
+	// 自选股分钟 K 线
+	SyncMinuteKLines(symbols)
 	// 运行所有策略
 	strategy.RunAll(symbols)
 	log.Println("Daily analysis finished")
 }
+
+// SyncMinuteKLines 按 config.minute_scales 抓取并保存自选股分钟 K 线
+func SyncMinuteKLines(symbols []string) {
+	datalen := config.Cfg.MinuteKLineLen
+	if datalen <= 0 {
+		datalen = 240
+	}
+	// 当前数据源不支持的周期整体跳过，只记一条日志
+	scales, unsupported := fetcher.SupportedMinuteScales(config.Cfg.MinuteScales)
+	if len(unsupported) > 0 {
+		log.Printf("minute_scales %v not supported by current data source, skipped", unsupported)
+	}
+	for _, sym := range symbols {
+		for _, scale := range scales {
+			n, err := fetcher.SyncMinuteKLine(sym, scale, datalen)
+			if err != nil {
+				log.Printf("sync %d-minute kline %s error: %v", scale, sym, err)
+				continue
+			}
+			log.Printf("synced %d-minute kline %s: %d bars", scale, sym, n)
+		}
+	}
+}
+
+// RefreshStockList 刷新证券列表并输出变更记录
+func RefreshStockList() {
+	changes, err := fetcher.RefreshStockList()
+	if err != nil {
+		log.Printf("refresh stock list failed: %v", err)
+		return
+	}
+	counts := map[string]int{}
+	for _, ch := range changes {
+		counts[ch.ChangeType]++
+	}
+	log.Printf("stock list refreshed: %d changes %v", len(changes), counts)
+}
@@ -0,0 +1,100 @@
+package storage
+
+import "sort"
+
+// 复权方式
+const (
+	AdjustNone = ""    // 不复权
+	AdjustQFQ  = "qfq" // 前复权：以最新价格为基准向前调整
+	AdjustHFQ  = "hfq" // 后复权：以上市首日为基准向后调整
+)
+
+// AdjFactor 后复权因子，从 Date 起生效直到下一条记录
+type AdjFactor struct {
+	Code   string  `json:"code"`
+	Date   string  `json:"date"`
+	Factor float64 `json:"factor"`
+}
+
+// InitAdjFactorTable 复权因子表
+func InitAdjFactorTable() error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS adj_factor (
+		code TEXT, date TEXT, factor REAL,
+		PRIMARY KEY(code,date)
+	)`)
+	return err
+}
+
+// SaveAdjFactors 用最新抓取的因子整体替换指定股票的复权因子
+func SaveAdjFactors(code string, factors []AdjFactor) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM adj_factor WHERE code=?`, code); err != nil {
+		tx.Rollback()
+		return err
+	}
+	stmt, err := tx.Prepare(`INSERT OR REPLACE INTO adj_factor(code,date,factor) VALUES(?,?,?)`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+	for _, f := range factors {
+		if _, err := stmt.Exec(code, f.Date, f.Factor); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// LoadAdjFactors 按日期升序加载复权因子
+func LoadAdjFactors(code string) ([]AdjFactor, error) {
+	rows, err := db.Query(`SELECT date,factor FROM adj_factor WHERE code=? ORDER BY date ASC`, code)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []AdjFactor{}
+	for rows.Next() {
+		f := AdjFactor{Code: code}
+		if err := rows.Scan(&f.Date, &f.Factor); err != nil {
+			return nil, err
+		}
+		out = append(out, f)
+	}
+	return out, nil
+}
+
+// AdjustKLines 按复权方式返回调整后价格的副本（成交量与指标字段不变，指标需由调用方重算）。
+// factors 需按日期升序；前复权价 = 原价 × 当日因子 / 最新因子，后复权价 = 原价 × 当日因子。
+func AdjustKLines(klines []KLine, factors []AdjFactor, mode string) []KLine {
+	out := make([]KLine, len(klines))
+	copy(out, klines)
+	if mode == AdjustNone || len(factors) == 0 {
+		return out
+	}
+	base := 1.0
+	if mode == AdjustQFQ {
+		base = factors[len(factors)-1].Factor
+	}
+	for i := range out {
+		day := out[i].Date
+		if len(day) > 10 {
+			day = day[:10]
+		}
+		// 找到 Date <= 当日 的最后一个因子；早于首个因子的按首个因子处理
+		j := sort.Search(len(factors), func(j int) bool { return factors[j].Date > day }) - 1
+		if j < 0 {
+			j = 0
+		}
+		ratio := factors[j].Factor / base
+		out[i].Open *= ratio
+		out[i].High *= ratio
+		out[i].Low *= ratio
+		out[i].Close *= ratio
+	}
+	return out
+}
@@ -3,6 +3,7 @@ package storage
 import (
 	"database/sql"
 	"log"
+	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -10,14 +11,43 @@ import (
 
 var db *sql.DB
 
-// 股票基本信息
+// 证券类型
+const (
+	SecTypeStock = "stock" // A 股
+	SecTypeETF   = "etf"
+	SecTypeFund  = "fund" // LOF 等场内基金
+	SecTypeIndex = "index"
+)
+
+// 股票基本信息（证券主表）
 type StockInfo struct {
-	Symbol string  `json:"symbol"`
-	Code   string  `json:"code"`
-	Name   string  `json:"name"`
-	Market string  `json:"market"`
-	Board  string  `json:"board"`
-	Trade  float64 `json:"trade"`
+	Symbol    string  `json:"symbol"`
+	Code      string  `json:"code"`
+	Name      string  `json:"name"`
+	Market    string  `json:"market"`
+	Board     string  `json:"board"`
+	Trade     float64 `json:"trade"`
+	SecType   string  `json:"sec_type"`  // stock / etf / fund / index
+	Exchange  string  `json:"exchange"`  // SSE / SZSE / BSE
+	ListDate  string  `json:"list_date"` // 上市日期，数据源未提供时为空
+	IsST      bool    `json:"is_st"`
+	Suspended bool    `json:"suspended"`
+	// KeepSuspended 数据源此时无法判断停牌（如盘前按成交量判断），保存时沿用库中已有的 suspended
+	KeepSuspended bool `json:"-"`
+}
+
+// StockFilter 证券查询条件，零值字段不参与过滤
+type StockFilter struct {
+	Keyword      string
+	Board        string
+	SecType      string
+	Exchange     string
+	ST           *bool
+	Suspended    *bool
+	ListedAfter  string
+	ListedBefore string
+	// 默认不返回已退市证券
+	IncludeDelisted bool
 }
 // KLine 日 K 线数据及指标
 type KLine struct {
@@ -56,11 +86,22 @@ func InitDB(path string) error {
 		market TEXT,
 		board TEXT,
 		trade REAL,
+		sec_type TEXT DEFAULT 'stock',
+		exchange TEXT,
+		list_date TEXT DEFAULT '',
+		is_st INTEGER DEFAULT 0,
+		suspended INTEGER DEFAULT 0,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);`
 	if _, err = db.Exec(stocksSQL); err != nil {
 		return err
 	}
+	if err = migrateStocksTable(); err != nil {
+		return err
+	}
+	if err = InitStockChangesTable(); err != nil {
+		return err
+	}
 	// 自选股表
 	watchSQL := `CREATE TABLE IF NOT EXISTS watchlist (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -94,24 +135,93 @@ func InitDB(path string) error {
 	if err != nil {
 		return err
 	}
+	if err = InitAdjFactorTable(); err != nil {
+		return err
+	}
+	if err = InitMinuteKLineTable(); err != nil {
+		return err
+	}
+	if err = InitKLineIssuesTable(); err != nil {
+		return err
+	}
+	if err = InitJobsTables(); err != nil {
+		return err
+	}
 
 	return nil
 }
 
-// SaveStocks 批量保存股票基本信息
+// migrateStocksTable 为旧库的 stocks 表补充证券主表字段。
+// 旧库只保存了四大板块的 A 股，所以 sec_type 默认为 stock，exchange 由 market 推出。
+func migrateStocksTable() error {
+	cols := []struct{ name, def string }{
+		{"sec_type", "TEXT DEFAULT 'stock'"},
+		{"exchange", "TEXT"},
+		{"list_date", "TEXT DEFAULT ''"},
+		{"is_st", "INTEGER DEFAULT 0"},
+		{"suspended", "INTEGER DEFAULT 0"},
+	}
+	for _, c := range cols {
+		if err := ensureColumn("stocks", c.name, c.def); err != nil {
+			return err
+		}
+	}
+	_, err := db.Exec(`UPDATE stocks SET exchange = CASE market WHEN 'SH' THEN 'SSE' WHEN 'SZ' THEN 'SZSE' WHEN 'BJ' THEN 'BSE' END WHERE exchange IS NULL`)
+	return err
+}
+
+// ensureColumn 表中不存在该列时执行 ALTER TABLE ADD COLUMN
+func ensureColumn(table, column, def string) error {
+	cols, err := tableColumns(table)
+	if err != nil {
+		return err
+	}
+	if cols[column] {
+		return nil
+	}
+	_, err = db.Exec("ALTER TABLE " + table + " ADD COLUMN " + column + " " + def)
+	return err
+}
+
+// tableColumns 返回表的列名集合，表不存在时为空
+func tableColumns(table string) (map[string]bool, error) {
+	rows, err := db.Query("PRAGMA table_info(" + table + ")")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	cols := map[string]bool{}
+	for rows.Next() {
+		var cid, notnull, pk int
+		var name, typ string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &typ, &notnull, &dflt, &pk); err != nil {
+			return nil, err
+		}
+		cols[name] = true
+	}
+	return cols, rows.Err()
+}
+
+// SaveStocks 批量保存股票基本信息（upsert；数据源未提供上市日期时保留库中已有值）
 func SaveStocks(list []StockInfo) error {
 	tx, err := db.Begin()
 	if err != nil {
 		return err
 	}
-	stmt, err := tx.Prepare(`INSERT OR REPLACE INTO stocks(symbol,code,name,market,board,trade,updated_at) VALUES(?,?,?,?,?,?,?)`)
+	stmt, err := tx.Prepare(`INSERT INTO stocks(symbol,code,name,market,board,trade,sec_type,exchange,list_date,is_st,suspended,updated_at) VALUES(?,?,?,?,?,?,?,?,?,?,?,?)
+		ON CONFLICT(symbol) DO UPDATE SET code=excluded.code, name=excluded.name, market=excluded.market, board=excluded.board,
+			trade=excluded.trade, sec_type=excluded.sec_type, exchange=excluded.exchange,
+			list_date=CASE WHEN excluded.list_date = '' THEN stocks.list_date ELSE excluded.list_date END,
+			is_st=excluded.is_st, suspended=CASE WHEN ? THEN stocks.suspended ELSE excluded.suspended END, updated_at=excluded.updated_at`)
 	if err != nil {
+		tx.Rollback()
 		return err
 	}
 	defer stmt.Close()
 	now := time.Now().Format("2006-01-02 15:04:05")
 	for _, s := range list {
-		if _, err := stmt.Exec(s.Symbol, s.Code, s.Name, s.Market, s.Board, s.Trade, now); err != nil {
+		if _, err := stmt.Exec(s.Symbol, s.Code, s.Name, s.Market, s.Board, s.Trade, s.SecType, s.Exchange, s.ListDate, s.IsST, s.Suspended, now, s.KeepSuspended); err != nil {
 			tx.Rollback()
 			return err
 		}
@@ -119,9 +229,14 @@ func SaveStocks(list []StockInfo) error {
 	return tx.Commit()
 }
 
-// CountStocksByBoards 返回指定板块的股票数量
+// CountStocksByBoards 返回证券主表中各已知板块的证券数量
 func CountStocksByBoards() (int, error) {
-	row := db.QueryRow(`SELECT COUNT(*) FROM stocks WHERE board IN ('上证主板','深证主板','创业板','科创板')`)
+	boards := QueryAllBoards()
+	args := make([]interface{}, len(boards))
+	for i, b := range boards {
+		args[i] = b
+	}
+	row := db.QueryRow(`SELECT COUNT(*) FROM stocks WHERE board IN (?`+strings.Repeat(",?", len(boards)-1)+`)`, args...)
 	var n int
 	if err := row.Scan(&n); err != nil {
 		return 0, err
@@ -129,19 +244,53 @@ func CountStocksByBoards() (int, error) {
 	return n, nil
 }
 
-// QueryStocks 按条件分页查询股票列表
+// QueryStocks 按关键字与板块分页查询；未指定板块时只返回 A 股
 func QueryStocks(keyword, board string, offset, limit int) ([]StockInfo, int, error) {
+	return QueryStocksFilter(StockFilter{Keyword: keyword, Board: board}, offset, limit)
+}
+
+// QueryStocksFilter 按条件分页查询证券主表；未指定板块和类型时只返回 A 股
+func QueryStocksFilter(f StockFilter, offset, limit int) ([]StockInfo, int, error) {
 	args := []interface{}{}
 	where := " WHERE 1=1 "
-	if board != "" {
+	if f.Board != "" {
 		where += " AND board = ? "
-		args = append(args, board)
-	} else {
-		where += " AND board IN ('上证主板','深证主板','创业板','科创板') "
+		args = append(args, f.Board)
+	}
+	if f.SecType != "" {
+		where += " AND sec_type = ? "
+		args = append(args, f.SecType)
+	} else if f.Board == "" {
+		where += " AND sec_type = ? "
+		args = append(args, SecTypeStock)
+	}
+	if f.Exchange != "" {
+		where += " AND exchange = ? "
+		args = append(args, f.Exchange)
+	}
+	if f.ST != nil {
+		where += " AND is_st = ? "
+		args = append(args, *f.ST)
+	}
+	if f.Suspended != nil {
+		where += " AND suspended = ? "
+		args = append(args, *f.Suspended)
+	}
+	// 上市日期未知（新浪列表不提供）的证券不参与上市日期过滤
+	if f.ListedAfter != "" {
+		where += " AND (list_date = '' OR list_date >= ?) "
+		args = append(args, f.ListedAfter)
+	}
+	if f.ListedBefore != "" {
+		where += " AND (list_date = '' OR list_date <= ?) "
+		args = append(args, f.ListedBefore)
+	}
+	if !f.IncludeDelisted {
+		where += " AND delisted = 0 "
 	}
-	if keyword != "" {
+	if f.Keyword != "" {
 		where += " AND (name LIKE ? OR code LIKE ? OR symbol LIKE ?) "
-		kw := "%" + keyword + "%"
+		kw := "%" + f.Keyword + "%"
 		args = append(args, kw, kw, kw)
 	}
 	countSQL := "SELECT COUNT(*) FROM stocks " + where
@@ -149,7 +298,7 @@ func QueryStocks(keyword, board string, offset, limit int) ([]StockInfo, int, er
 	if err := db.QueryRow(countSQL, args...).Scan(&total); err != nil {
 		return nil, 0, err
 	}
-	querySQL := "SELECT symbol,code,name,market,board,trade FROM stocks " + where + " ORDER BY code LIMIT ? OFFSET ?"
+	querySQL := "SELECT symbol,code,name,market,board,trade,sec_type,exchange,list_date,is_st,suspended FROM stocks " + where + " ORDER BY code LIMIT ? OFFSET ?"
 	args = append(args, limit, offset)
 	rows, err := db.Query(querySQL, args...)
 	if err != nil {
@@ -159,9 +308,11 @@ func QueryStocks(keyword, board string, offset, limit int) ([]StockInfo, int, er
 	out := []StockInfo{}
 	for rows.Next() {
 		var s StockInfo
-		if err := rows.Scan(&s.Symbol, &s.Code, &s.Name, &s.Market, &s.Board, &s.Trade); err != nil {
+		var secType, exchange, listDate sql.NullString
+		if err := rows.Scan(&s.Symbol, &s.Code, &s.Name, &s.Market, &s.Board, &s.Trade, &secType, &exchange, &listDate, &s.IsST, &s.Suspended); err != nil {
 			return nil, 0, err
 		}
+		s.SecType, s.Exchange, s.ListDate = secType.String, exchange.String, listDate.String
 		out = append(out, s)
 	}
 	return out, total, nil
@@ -183,6 +334,35 @@ func RemoveFromWatchlist(symbol string) error {
 	_, err := db.Exec("DELETE FROM watchlist WHERE symbol = ?", symbol)
 	return err
 }
+// ImportWatchlist 批量导入自选股，replace 为 true 时先清空；在同一事务中完成，失败时原列表不变。
+// 返回实际新增的条数（已在自选股中的不计）
+func ImportWatchlist(items []WatchStock, replace bool) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	if replace {
+		if _, err := tx.Exec("DELETE FROM watchlist"); err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+	n := 0
+	for _, it := range items {
+		res, err := tx.Exec("INSERT OR IGNORE INTO watchlist(symbol,name) VALUES(?,?)", it.Symbol, it.Name)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		if a, err := res.RowsAffected(); err == nil {
+			n += int(a)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
 // 获取自选股列表
 func GetWatchlist() ([]WatchStock, error) {
 	rows, err := db.Query("SELECT symbol,name,added_at FROM watchlist ORDER BY added_at DESC")
@@ -201,6 +381,22 @@ func GetWatchlist() ([]WatchStock, error) {
 	return out, nil
 }
 
+// GetStock 按 symbol 查询证券主表记录，不存在时返回 nil
+func GetStock(symbol string) (*StockInfo, error) {
+	var s StockInfo
+	var secType, exchange, listDate sql.NullString
+	err := db.QueryRow(`SELECT symbol,code,name,market,board,sec_type,exchange,list_date,is_st,suspended FROM stocks WHERE symbol=?`, symbol).
+		Scan(&s.Symbol, &s.Code, &s.Name, &s.Market, &s.Board, &secType, &exchange, &listDate, &s.IsST, &s.Suspended)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.SecType, s.Exchange, s.ListDate = secType.String, exchange.String, listDate.String
+	return &s, nil
+}
+
 // 保存K线数据
 func SaveKLines(code string, klines []KLine) error {
 	tx, err := db.Begin()
@@ -220,9 +416,52 @@ func SaveKLines(code string, klines []KLine) error {
 	}
 	return tx.Commit()
 }
-// LoadKLines 加载指定股票的最近 N 天 K 线数据
+// ReplaceKLines 在同一事务中删除指定股票的全部日 K 线并写入 klines，失败时原数据不变；返回写入行数
+func ReplaceKLines(code string, klines []KLine) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec("DELETE FROM kline WHERE code=?", code); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	stmt, err := tx.Prepare(`INSERT OR REPLACE INTO kline(code,date,open,close,high,low,volume,ma5,ma10,ma20,ma30,dif,dea,macd) VALUES(?,?,?,?,?,?,?,?,?,?,?,?,?,?)`)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	defer stmt.Close()
+	n := 0
+	for _, k := range klines {
+		res, err := stmt.Exec(code, k.Date, k.Open, k.Close, k.High, k.Low, k.Volume, k.MA5, k.MA10, k.MA20, k.MA30, k.DIF, k.DEA, k.MACD)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		if a, err := res.RowsAffected(); err == nil {
+			n += int(a)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// LoadKLines 加载指定股票的最近 N 天 K 线数据（按日期升序）。
+// 注意：早期实现是 ORDER BY date ASC LIMIT N，实际返回的是最早的 N 条，
+// 库中历史超过 N 条时策略会跑在旧数据上；现改为取最近 N 条，调用方拿到的末根即最新 K 线。
 func LoadKLines(code string, days int) ([]KLine, error) {
-	rows, err := db.Query("SELECT date,open,high,low,close,volume,ma5,ma10,ma20,ma30,dif,dea,macd FROM kline WHERE code=? ORDER BY date ASC LIMIT ?", code, days)
+	return LoadKLinesUntil(code, "", days)
+}
+
+// LoadKLinesUntil 加载日期不晚于 end 的最近 N 天 K 线（按日期升序），end 为空时不限制
+func LoadKLinesUntil(code, end string, days int) ([]KLine, error) {
+	if end == "" {
+		end = "9999-12-31"
+	}
+	rows, err := db.Query("SELECT * FROM (SELECT date,open,high,low,close,volume,ma5,ma10,ma20,ma30,dif,dea,macd FROM kline WHERE code=? AND date <= ? ORDER BY date DESC LIMIT ?) ORDER BY date ASC", code, end, days)
 	if err != nil {
 		return nil, err
 	}
@@ -239,6 +478,21 @@ func LoadKLines(code string, days int) ([]KLine, error) {
 	return res, nil
 }
 
+// LoadAllKLines 加载指定股票库中全部 K 线（按日期升序）
+func LoadAllKLines(code string) ([]KLine, error) {
+	// SQLite 中 LIMIT -1 表示不限制条数
+	return LoadKLines(code, -1)
+}
+
+// LastKLineDate 返回指定股票库中最新一根 K 线的日期，无数据时返回空串
+func LastKLineDate(code string) (string, error) {
+	var last sql.NullString
+	if err := db.QueryRow("SELECT MAX(date) FROM kline WHERE code=?", code).Scan(&last); err != nil {
+		return "", err
+	}
+	return last.String, nil
+}
+
 // SaveResult 保存自动选股结果
 func SaveResult(code, date, strategy string) error {
 	_, err := db.Exec("INSERT OR REPLACE INTO results(code,date,strategy) VALUES (?,?,?)", code, date, strategy)
@@ -246,7 +500,7 @@ func SaveResult(code, date, strategy string) error {
 }
 
 func QueryAllBoards() []string {
-	return []string{"上证主板", "深证主板", "创业板", "科创板"}
+	return []string{"上证主板", "深证主板", "中小板", "创业板", "科创板", "北交所", "ETF", "基金", "指数"}
 }
 
 // Utility
@@ -0,0 +1,204 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// 后台任务状态
+const (
+	JobRunning  = "running"
+	JobStopped  = "stopped"
+	JobFinished = "finished"
+	JobFailed   = "failed"
+)
+
+// 任务中单只证券的检查点状态
+const (
+	CheckpointPending = "pending"
+	CheckpointDone    = "done"
+	CheckpointFailed  = "failed"
+)
+
+// JobRecord 后台任务（每个任务名一条，保存最近一次运行）
+type JobRecord struct {
+	Name       string `json:"name"`
+	State      string `json:"state"`
+	Params     string `json:"params"`
+	Error      string `json:"error"`
+	StartedAt  string `json:"started_at"`
+	UpdatedAt  string `json:"updated_at"`
+	FinishedAt string `json:"finished_at"`
+}
+
+// InitJobsTables 任务表与按证券的检查点表，用于中断（停止或进程崩溃）后断点续跑
+func InitJobsTables() error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS jobs (
+		name TEXT PRIMARY KEY,
+		state TEXT,
+		params TEXT,
+		error TEXT DEFAULT '',
+		started_at TEXT,
+		updated_at TEXT,
+		finished_at TEXT DEFAULT ''
+	)`); err != nil {
+		return err
+	}
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS job_checkpoints (
+		job TEXT,
+		symbol TEXT,
+		status TEXT,
+		rows INTEGER DEFAULT 0,
+		attempts INTEGER DEFAULT 0,
+		error TEXT DEFAULT '',
+		updated_at TEXT,
+		PRIMARY KEY(job, symbol)
+	)`)
+	return err
+}
+
+func nowString() string { return time.Now().Format("2006-01-02 15:04:05") }
+
+// GetJob 查询任务记录，不存在时返回 nil
+func GetJob(name string) (*JobRecord, error) {
+	var j JobRecord
+	err := db.QueryRow(`SELECT name,state,params,error,started_at,updated_at,finished_at FROM jobs WHERE name=?`, name).
+		Scan(&j.Name, &j.State, &j.Params, &j.Error, &j.StartedAt, &j.UpdatedAt, &j.FinishedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+// ListJobs 返回所有任务记录
+func ListJobs() ([]JobRecord, error) {
+	rows, err := db.Query(`SELECT name,state,params,error,started_at,updated_at,finished_at FROM jobs ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []JobRecord{}
+	for rows.Next() {
+		var j JobRecord
+		if err := rows.Scan(&j.Name, &j.State, &j.Params, &j.Error, &j.StartedAt, &j.UpdatedAt, &j.FinishedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, j)
+	}
+	return out, nil
+}
+
+// ResetJob 开始一次新的运行：记录参数并把检查点重置为 symbols 全部待处理
+func ResetJob(name, params string, symbols []string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	now := nowString()
+	if _, err := tx.Exec(`INSERT OR REPLACE INTO jobs(name,state,params,error,started_at,updated_at,finished_at) VALUES(?,?,?,'',?,?,'')`,
+		name, JobRunning, params, now, now); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM job_checkpoints WHERE job=?`, name); err != nil {
+		tx.Rollback()
+		return err
+	}
+	stmt, err := tx.Prepare(`INSERT INTO job_checkpoints(job,symbol,status,updated_at) VALUES(?,?,?,?)`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+	for _, s := range symbols {
+		if _, err := stmt.Exec(name, s, CheckpointPending, now); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// SetJobState 更新任务状态；结束状态同时记录结束时间
+func SetJobState(name, state, errStr string) error {
+	now := nowString()
+	finished := ""
+	if state == JobFinished || state == JobFailed || state == JobStopped {
+		finished = now
+	}
+	_, err := db.Exec(`UPDATE jobs SET state=?, error=?, updated_at=?, finished_at=? WHERE name=?`, state, errStr, now, finished, name)
+	return err
+}
+
+// PendingJobSymbols 返回尚未完成的证券：待处理的，以及失败次数少于 maxAttempts 的
+func PendingJobSymbols(name string, maxAttempts int) ([]string, error) {
+	rows, err := db.Query(`SELECT symbol FROM job_checkpoints WHERE job=? AND (status=? OR (status=? AND attempts<?)) ORDER BY symbol`,
+		name, CheckpointPending, CheckpointFailed, maxAttempts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []string{}
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// SetJobCheckpoint 记录单只证券的处理结果
+func SetJobCheckpoint(name, symbol, status string, n int, errStr string) error {
+	now := nowString()
+	_, err := db.Exec(`UPDATE job_checkpoints SET status=?, rows=?, error=?, attempts=attempts+1, updated_at=? WHERE job=? AND symbol=?`,
+		status, n, errStr, now, name, symbol)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`UPDATE jobs SET updated_at=? WHERE name=?`, now, name)
+	return err
+}
+
+// JobProgress 按检查点状态统计数量（pending/done/failed）以及累计写入行数
+func JobProgress(name string) (map[string]int, int, error) {
+	rows, err := db.Query(`SELECT status,COUNT(*),COALESCE(SUM(rows),0) FROM job_checkpoints WHERE job=? GROUP BY status`, name)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	out := map[string]int{CheckpointPending: 0, CheckpointDone: 0, CheckpointFailed: 0}
+	total := 0
+	for rows.Next() {
+		var st string
+		var n, r int
+		if err := rows.Scan(&st, &n, &r); err != nil {
+			return nil, 0, err
+		}
+		out[st] = n
+		total += r
+	}
+	return out, total, nil
+}
+
+// JobFailures 返回失败的证券及错误信息
+func JobFailures(name string, limit int) (map[string]string, error) {
+	rows, err := db.Query(`SELECT symbol,error FROM job_checkpoints WHERE job=? AND status=? ORDER BY updated_at DESC LIMIT ?`, name, CheckpointFailed, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[string]string{}
+	for rows.Next() {
+		var s, e string
+		if err := rows.Scan(&s, &e); err != nil {
+			return nil, err
+		}
+		out[s] = e
+	}
+	return out, nil
+}
@@ -0,0 +1,179 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// KLineIssue K 线数据质量问题
+type KLineIssue struct {
+	Symbol     string `json:"symbol"`
+	Date       string `json:"date"`
+	IssueType  string `json:"issue_type"`
+	Severity   string `json:"severity"` // error | warn
+	Detail     string `json:"detail"`
+	DetectedAt string `json:"detected_at"`
+}
+
+// KLineIssueSummary 单只证券的问题汇总
+type KLineIssueSummary struct {
+	Symbol     string         `json:"symbol"`
+	Total      int            `json:"total"`
+	Errors     int            `json:"errors"`
+	ByType     map[string]int `json:"by_type"`
+	DetectedAt string         `json:"detected_at"`
+}
+
+// InitKLineIssuesTable 数据质量问题表：每次校验会整体替换该证券的记录。
+// 同一日期可能有多条同类问题（如重复与乱序），seq 为其在 (symbol, date, issue_type) 内的序号
+func InitKLineIssuesTable() error {
+	// 旧表主键不含 seq，同日同类问题会互相覆盖；表内容可由下次校验重新生成，直接重建
+	if cols, err := tableColumns("kline_issues"); err != nil {
+		return err
+	} else if len(cols) > 0 && !cols["seq"] {
+		if _, err := db.Exec(`DROP TABLE kline_issues`); err != nil {
+			return err
+		}
+	}
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS kline_issues (
+		symbol TEXT,
+		date TEXT,
+		issue_type TEXT,
+		seq INTEGER DEFAULT 0,
+		severity TEXT,
+		detail TEXT,
+		detected_at DATETIME,
+		PRIMARY KEY(symbol, date, issue_type, seq)
+	)`)
+	return err
+}
+
+// ReplaceKLineIssues 删除 symbol 旧的问题记录并写入本次校验结果；
+// 与上次相同的问题（日期、类型、详情一致）沿用首次发现的 detected_at
+func ReplaceKLineIssues(symbol string, issues []KLineIssue) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	firstSeen := map[string]string{}
+	rows, err := tx.Query(`SELECT date,issue_type,detail,detected_at FROM kline_issues WHERE symbol=?`, symbol)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	for rows.Next() {
+		var date, typ, detail string
+		var at sql.NullString
+		if err := rows.Scan(&date, &typ, &detail, &at); err != nil {
+			rows.Close()
+			tx.Rollback()
+			return err
+		}
+		firstSeen[date+"|"+typ+"|"+detail] = at.String
+	}
+	rows.Close()
+	if _, err := tx.Exec(`DELETE FROM kline_issues WHERE symbol=?`, symbol); err != nil {
+		tx.Rollback()
+		return err
+	}
+	now := time.Now().Format("2006-01-02 15:04:05")
+	stmt, err := tx.Prepare(`INSERT INTO kline_issues(symbol,date,issue_type,seq,severity,detail,detected_at) VALUES(?,?,?,?,?,?,?)`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+	seq := map[string]int{}
+	for _, is := range issues {
+		key := is.Date + "|" + is.IssueType
+		at := firstSeen[key+"|"+is.Detail]
+		if at == "" {
+			at = now
+		}
+		if _, err := stmt.Exec(symbol, is.Date, is.IssueType, seq[key], is.Severity, is.Detail, at); err != nil {
+			tx.Rollback()
+			return err
+		}
+		seq[key]++
+	}
+	return tx.Commit()
+}
+
+// QueryKLineIssues 分页查询问题明细（按证券、日期排序），参数为空时不过滤
+func QueryKLineIssues(symbol, issueType, severity string, offset, limit int) ([]KLineIssue, int, error) {
+	where := " WHERE 1=1 "
+	args := []interface{}{}
+	if symbol != "" {
+		where += " AND symbol = ? "
+		args = append(args, symbol)
+	}
+	if issueType != "" {
+		where += " AND issue_type = ? "
+		args = append(args, issueType)
+	}
+	if severity != "" {
+		where += " AND severity = ? "
+		args = append(args, severity)
+	}
+	var total int
+	if err := db.QueryRow("SELECT COUNT(*) FROM kline_issues"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	args = append(args, limit, offset)
+	rows, err := db.Query("SELECT symbol,date,issue_type,severity,detail,detected_at FROM kline_issues"+where+" ORDER BY symbol, date LIMIT ? OFFSET ?", args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	out := []KLineIssue{}
+	for rows.Next() {
+		var is KLineIssue
+		if err := rows.Scan(&is.Symbol, &is.Date, &is.IssueType, &is.Severity, &is.Detail, &is.DetectedAt); err != nil {
+			return nil, 0, err
+		}
+		out = append(out, is)
+	}
+	return out, total, nil
+}
+
+// SummarizeKLineIssues 按证券汇总问题数量，symbol 为空时返回所有有问题的证券
+func SummarizeKLineIssues(symbol string) ([]KLineIssueSummary, error) {
+	q := `SELECT symbol,issue_type,severity,COUNT(*),MAX(detected_at) FROM kline_issues`
+	args := []interface{}{}
+	if symbol != "" {
+		q += " WHERE symbol = ?"
+		args = append(args, symbol)
+	}
+	q += " GROUP BY symbol,issue_type,severity ORDER BY symbol"
+	rows, err := db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []KLineIssueSummary{}
+	idx := map[string]int{}
+	for rows.Next() {
+		var sym, typ, sev string
+		var n int
+		var at sql.NullString
+		if err := rows.Scan(&sym, &typ, &sev, &n, &at); err != nil {
+			return nil, err
+		}
+		i, ok := idx[sym]
+		if !ok {
+			out = append(out, KLineIssueSummary{Symbol: sym, ByType: map[string]int{}})
+			i = len(out) - 1
+			idx[sym] = i
+		}
+		s := &out[i]
+		s.Total += n
+		s.ByType[typ] += n
+		if sev == "error" {
+			s.Errors += n
+		}
+		if at.String > s.DetectedAt {
+			s.DetectedAt = at.String
+		}
+	}
+	return out, nil
+}
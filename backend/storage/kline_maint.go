@@ -0,0 +1,41 @@
+package storage
+
+// KLineSymbols 返回 kline 表中有数据的全部代码
+func KLineSymbols() ([]string, error) {
+	rows, err := db.Query("SELECT DISTINCT code FROM kline ORDER BY code")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []string{}
+	for rows.Next() {
+		var code string
+		if err := rows.Scan(&code); err != nil {
+			return nil, err
+		}
+		out = append(out, code)
+	}
+	return out, rows.Err()
+}
+
+// UpdateKLineIndicators 在一个事务中更新指定股票若干 K 线的指标列（不改动 OHLCV），
+// 任一行失败则整体回滚
+func UpdateKLineIndicators(code string, klines []KLine) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(`UPDATE kline SET ma5=?, ma10=?, ma20=?, ma30=?, dif=?, dea=?, macd=? WHERE code=? AND date=?`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+	for _, k := range klines {
+		if _, err := stmt.Exec(k.MA5, k.MA10, k.MA20, k.MA30, k.DIF, k.DEA, k.MACD, code, k.Date); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,70 @@
+package storage
+
+// InitMinuteKLineTable 分钟 K 线表，scale 为分钟数，time 形如 "2006-01-02 15:04:05"
+func InitMinuteKLineTable() error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS minute_kline (
+		code TEXT, scale INTEGER, time TEXT,
+		open REAL, close REAL, high REAL, low REAL, volume REAL,
+		PRIMARY KEY(code,scale,time)
+	)`)
+	return err
+}
+
+// SaveMinuteKLines 保存分钟 K 线（KLine.Date 为 bar 结束时间）
+func SaveMinuteKLines(code string, scale int, klines []KLine) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(`INSERT OR REPLACE INTO minute_kline(code,scale,time,open,close,high,low,volume) VALUES(?,?,?,?,?,?,?,?)`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+	for _, k := range klines {
+		if _, err := stmt.Exec(code, scale, k.Date, k.Open, k.Close, k.High, k.Low, k.Volume); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// LoadMinuteKLines 按时间区间加载分钟 K 线（按时间升序）。
+// start/end 可为 "2006-01-02" 或完整时间，end 只给日期时包含当天全部数据；
+// limit > 0 时只返回区间内最近 limit 条。
+func LoadMinuteKLines(code string, scale int, start, end string, limit int) ([]KLine, error) {
+	where := " WHERE code=? AND scale=? "
+	args := []interface{}{code, scale}
+	if start != "" {
+		where += " AND time >= ? "
+		args = append(args, start)
+	}
+	if end != "" {
+		if len(end) == 10 {
+			end += " 23:59:59"
+		}
+		where += " AND time <= ? "
+		args = append(args, end)
+	}
+	q := "SELECT time,open,high,low,close,volume FROM minute_kline" + where + " ORDER BY time DESC"
+	if limit > 0 {
+		q += " LIMIT ?"
+		args = append(args, limit)
+	}
+	rows, err := db.Query("SELECT * FROM ("+q+") ORDER BY time ASC", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := []KLine{}
+	for rows.Next() {
+		k := KLine{Code: code}
+		if err := rows.Scan(&k.Date, &k.Open, &k.High, &k.Low, &k.Close, &k.Volume); err != nil {
+			return nil, err
+		}
+		res = append(res, k)
+	}
+	return res, nil
+}
@@ -0,0 +1,232 @@
+package storage
+
+import (
+	"log"
+	"time"
+)
+
+// 证券列表变更类型
+const (
+	ChangeNew        = "new"
+	ChangeDelisted   = "delisted"
+	ChangeRelisted   = "relisted"
+	ChangeRenamed    = "renamed"
+	ChangeBoardMoved = "board_moved"
+)
+
+// StockChange 证券列表的一条变更记录
+type StockChange struct {
+	ID         int64  `json:"id"`
+	Symbol     string `json:"symbol"`
+	ChangeType string `json:"change_type"`
+	OldValue   string `json:"old_value"`
+	NewValue   string `json:"new_value"`
+	Date       string `json:"date"`
+}
+
+// delistGuardRatio 本次抓取数量低于库中同类证券数量的该比例时，认为抓取不完整，不做退市判定
+const delistGuardRatio = 0.9
+
+// InitStockChangesTable 变更日志表，并为 stocks 表补充退市/首次出现字段
+func InitStockChangesTable() error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS stock_changes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		symbol TEXT,
+		change_type TEXT,
+		old_value TEXT,
+		new_value TEXT,
+		date TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return err
+	}
+	if _, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_stock_changes_symbol ON stock_changes(symbol, date)`); err != nil {
+		return err
+	}
+	cols := []struct{ name, def string }{
+		{"delisted", "INTEGER DEFAULT 0"},
+		{"delisted_at", "TEXT DEFAULT ''"},
+		{"first_seen", "TEXT DEFAULT ''"},
+	}
+	for _, c := range cols {
+		if err := ensureColumn("stocks", c.name, c.def); err != nil {
+			return err
+		}
+	}
+	// 旧数据没有 first_seen，用 updated_at 的日期兜底
+	_, err = db.Exec(`UPDATE stocks SET first_seen = substr(updated_at,1,10) WHERE first_seen = '' OR first_seen IS NULL`)
+	return err
+}
+
+type stockState struct {
+	name, board, secType string
+	delisted             bool
+}
+
+// SyncStocks 将最新抓取的证券列表与库中对比，写入变更日志并更新 stocks 表，返回本次变更。
+// date 为变更日期（YYYY-MM-DD）。首次初始化（库为空）时只入库不记日志；
+// 退市只在本次列表包含的证券类型内判定，且抓取数量明显偏少时跳过，避免接口异常导致误判。
+func SyncStocks(list []StockInfo, date string) ([]StockChange, error) {
+	rows, err := db.Query(`SELECT symbol,name,board,sec_type,delisted FROM stocks`)
+	if err != nil {
+		return nil, err
+	}
+	existing := map[string]stockState{}
+	activeByType := map[string]int{}
+	for rows.Next() {
+		var sym string
+		var st stockState
+		if err := rows.Scan(&sym, &st.name, &st.board, &st.secType, &st.delisted); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		existing[sym] = st
+		if !st.delisted {
+			activeByType[st.secType]++
+		}
+	}
+	rows.Close()
+
+	bootstrap := len(existing) == 0
+	changes := []StockChange{}
+	fetched := map[string]bool{}
+	fetchedByType := map[string]int{}
+	for _, s := range list {
+		fetched[s.Symbol] = true
+		fetchedByType[s.SecType]++
+		old, ok := existing[s.Symbol]
+		switch {
+		case !ok:
+			changes = append(changes, StockChange{Symbol: s.Symbol, ChangeType: ChangeNew, NewValue: s.Name, Date: date})
+		case old.delisted:
+			changes = append(changes, StockChange{Symbol: s.Symbol, ChangeType: ChangeRelisted, NewValue: s.Name, Date: date})
+		}
+		if ok && old.name != s.Name {
+			changes = append(changes, StockChange{Symbol: s.Symbol, ChangeType: ChangeRenamed, OldValue: old.name, NewValue: s.Name, Date: date})
+		}
+		if ok && old.board != s.Board {
+			changes = append(changes, StockChange{Symbol: s.Symbol, ChangeType: ChangeBoardMoved, OldValue: old.board, NewValue: s.Board, Date: date})
+		}
+	}
+	for secType, n := range fetchedByType {
+		if float64(n) < float64(activeByType[secType])*delistGuardRatio {
+			log.Printf("stock sync: fetched %d %s securities but %d active in DB, skip delisting", n, secType, activeByType[secType])
+			delete(fetchedByType, secType)
+		}
+	}
+	for sym, old := range existing {
+		if old.delisted || fetched[sym] {
+			continue
+		}
+		if _, ok := fetchedByType[old.secType]; !ok {
+			continue
+		}
+		changes = append(changes, StockChange{Symbol: sym, ChangeType: ChangeDelisted, OldValue: old.name, Date: date})
+	}
+
+	if err := SaveStocks(list); err != nil {
+		return nil, err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Format("2006-01-02 15:04:05")
+	if _, err := tx.Exec(`UPDATE stocks SET first_seen=? WHERE first_seen = '' OR first_seen IS NULL`, date); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	for _, ch := range changes {
+		switch ch.ChangeType {
+		case ChangeDelisted:
+			_, err = tx.Exec(`UPDATE stocks SET delisted=1, delisted_at=? WHERE symbol=?`, date, ch.Symbol)
+		case ChangeRelisted:
+			_, err = tx.Exec(`UPDATE stocks SET delisted=0, delisted_at='' WHERE symbol=?`, ch.Symbol)
+		}
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if bootstrap {
+			continue
+		}
+		if _, err := tx.Exec(`INSERT INTO stock_changes(symbol,change_type,old_value,new_value,date,created_at) VALUES(?,?,?,?,?,?)`,
+			ch.Symbol, ch.ChangeType, ch.OldValue, ch.NewValue, ch.Date, now); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	if bootstrap {
+		return []StockChange{}, nil
+	}
+	return changes, nil
+}
+
+// QueryStockChanges 分页查询变更日志（按日期倒序），参数为空时不过滤
+func QueryStockChanges(symbol, changeType, since string, offset, limit int) ([]StockChange, int, error) {
+	where := " WHERE 1=1 "
+	args := []interface{}{}
+	if symbol != "" {
+		where += " AND symbol = ? "
+		args = append(args, symbol)
+	}
+	if changeType != "" {
+		where += " AND change_type = ? "
+		args = append(args, changeType)
+	}
+	if since != "" {
+		where += " AND date >= ? "
+		args = append(args, since)
+	}
+	var total int
+	if err := db.QueryRow("SELECT COUNT(*) FROM stock_changes"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	args = append(args, limit, offset)
+	rows, err := db.Query("SELECT id,symbol,change_type,old_value,new_value,date FROM stock_changes"+where+" ORDER BY date DESC, id DESC LIMIT ? OFFSET ?", args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	out := []StockChange{}
+	for rows.Next() {
+		var ch StockChange
+		if err := rows.Scan(&ch.ID, &ch.Symbol, &ch.ChangeType, &ch.OldValue, &ch.NewValue, &ch.Date); err != nil {
+			return nil, 0, err
+		}
+		out = append(out, ch)
+	}
+	return out, total, nil
+}
+
+// UniverseAt 返回指定日期时点在市的证券（point-in-time 股票池），secType 为空时默认 A 股。
+// 上市日期已知时以上市日期为准，否则以库中最早一根日 K 的日期为准，两者都没有才用首次出现在列表中的日期；
+// 退市日期晚于 date 的仍算在市。首次建库时 first_seen 为当天，因此既无上市日期也无 K 线的证券在更早的日期不会出现。
+func UniverseAt(date, secType string) ([]StockInfo, error) {
+	if secType == "" {
+		secType = SecTypeStock
+	}
+	rows, err := db.Query(`SELECT symbol,code,name,market,board,sec_type,exchange,list_date FROM stocks
+		WHERE sec_type = ?
+		AND (CASE WHEN list_date != '' THEN list_date
+			ELSE COALESCE((SELECT MIN(k.date) FROM kline k WHERE k.code = stocks.symbol), first_seen) END) <= ?
+		AND (delisted = 0 OR delisted_at > ?)
+		ORDER BY code`, secType, date, date)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []StockInfo{}
+	for rows.Next() {
+		var s StockInfo
+		if err := rows.Scan(&s.Symbol, &s.Code, &s.Name, &s.Market, &s.Board, &s.SecType, &s.Exchange, &s.ListDate); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
@@ -0,0 +1,52 @@
+package strategy
+
+import (
+	"sort"
+
+	"go-stock-analyzer/backend/fetcher"
+	"go-stock-analyzer/backend/indicator"
+	"go-stock-analyzer/backend/storage"
+)
+
+// benchmarkAware 需要基准指数数据的策略，RunAll 会在运行前注入与策略同周期的基准 K 线
+type benchmarkAware interface {
+	SetBenchmark(bench []storage.KLine)
+}
+
+// benchmarkVars 计算 DSL 中的基准变量；没有基准数据时均为 0
+//   - bench_close / bench_ma20：对齐到最后一根 K 线的基准收盘价与 MA20
+//   - bench_pct：基准最后一根 K 线涨跌幅（%）
+//   - excess_ret20：个股近 20 根 K 线收益减去基准同期收益（%）
+func benchmarkVars(klines, bench []storage.KLine) map[string]interface{} {
+	vars := map[string]interface{}{
+		"bench_close":  0.0,
+		"bench_ma20":   0.0,
+		"bench_pct":    0.0,
+		"excess_ret20": 0.0,
+	}
+	if len(klines) == 0 || len(bench) == 0 {
+		return vars
+	}
+	aligned := fetcher.AlignBenchmark(klines, bench)
+	n := len(klines) - 1
+	b := aligned[n]
+	if b.Close <= 0 {
+		return vars
+	}
+	vars["bench_close"] = b.Close
+	// bench_ma20 由指标注册表按基准序列计算（与 DSL 的 ma20 同口径），取对齐到的那根基准 K 线
+	if j := sort.Search(len(bench), func(i int) bool { return bench[i].Date > klines[n].Date }) - 1; j >= 0 {
+		if ma, err := indicator.Cached(bench).Get("ma(20)"); err == nil {
+			vars["bench_ma20"] = ma[j]
+		}
+	}
+	if n > 0 && aligned[n-1].Close > 0 {
+		vars["bench_pct"] = (b.Close/aligned[n-1].Close - 1) * 100
+	}
+	if n >= 20 && klines[n-20].Close > 0 && aligned[n-20].Close > 0 {
+		ret := klines[n].Close/klines[n-20].Close - 1
+		benchRet := b.Close/aligned[n-20].Close - 1
+		vars["excess_ret20"] = (ret - benchRet) * 100
+	}
+	return vars
+}
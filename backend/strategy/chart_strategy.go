@@ -0,0 +1,49 @@
+package strategy
+
+import (
+	"go-stock-analyzer/backend/pattern"
+	"go-stock-analyzer/backend/storage"
+)
+
+// DefaultChartPatterns ChartPattern 策略未配置 types 时使用的看涨图表形态
+var DefaultChartPatterns = []string{"double_bottom", "inverse_head_and_shoulders", "ascending_triangle", "bull_flag", "range_breakout"}
+
+// ChartPatternStrategy 最近 Within 根 K 线内有指定图表形态确认突破（可要求放量）时入选
+type ChartPatternStrategy struct {
+	Types         []string
+	Within        int
+	RequireVolume bool
+	MinStrength   float64
+	Options       pattern.ChartOptions
+}
+
+func NewChartPatternStrategy(types []string, within int, requireVolume bool, minStrength float64) *ChartPatternStrategy {
+	if len(types) == 0 {
+		types = DefaultChartPatterns
+	}
+	if within <= 0 {
+		within = 3
+	}
+	return &ChartPatternStrategy{Types: types, Within: within, RequireVolume: requireVolume, MinStrength: minStrength, Options: pattern.DefaultChartOptions()}
+}
+
+func (s *ChartPatternStrategy) Name() string { return "ChartPattern" }
+
+func (s *ChartPatternStrategy) Match(code string, klines []storage.KLine) bool {
+	want := map[string]bool{}
+	for _, t := range s.Types {
+		want[t] = true
+	}
+	for _, p := range pattern.DetectChart(klines, s.Options) {
+		if !want[p.Type] || !p.Confirmed || p.BreakoutIndex < len(klines)-s.Within {
+			continue
+		}
+		if s.RequireVolume && !p.VolumeConfirmed {
+			continue
+		}
+		if p.Strength >= s.MinStrength {
+			return true
+		}
+	}
+	return false
+}
@@ -3,15 +3,27 @@ package strategy
 import (
 	"fmt"
 
+	"go-stock-analyzer/backend/indicator"
 	"go-stock-analyzer/backend/storage"
 
 	"github.com/Knetic/govaluate"
 )
 
+// dslVar 解析指标以外的 DSL 变量，不认识该名称时 ok 为 false
+type dslVar func(klines []storage.KLine, name string) (value interface{}, ok bool)
+
+// dslVars 按顺序尝试的变量解析器
+var dslVars = []dslVar{patternVar, levelsVar}
+
 type DSLStrategy struct {
 	Expr string
+	// 基准指数 K 线（与策略同周期），用于 bench_* / excess_ret20 变量
+	Benchmark []storage.KLine
 }
 
+// SetBenchmark 设置基准指数 K 线
+func (s *DSLStrategy) SetBenchmark(bench []storage.KLine) { s.Benchmark = bench }
+
 func NewDSLStrategy(expr string) *DSLStrategy {
 	return &DSLStrategy{Expr: expr}
 }
@@ -25,18 +37,14 @@ func (s *DSLStrategy) Match(code string, klines []storage.KLine) bool {
 	last := klines[len(klines)-1]
 
 	parameters := map[string]interface{}{
-		"close":     last.Close,
-		"open":      last.Open,
-		"high":      last.High,
-		"low":       last.Low,
-		"volume":    last.Volume,
-		"ma5":       last.MA5,
-		"ma10":      last.MA10,
-		"ma20":      last.MA20,
-		"ma30":      last.MA30,
-		"macd_dif":  last.DIF,
-		"macd_dea":  last.DEA,
-		"macd_hist": last.MACD,
+		"close":  last.Close,
+		"open":   last.Open,
+		"high":   last.High,
+		"low":    last.Low,
+		"volume": last.Volume,
+	}
+	for k, v := range benchmarkVars(klines, s.Benchmark) {
+		parameters[k] = v
 	}
 
 	expr, err := govaluate.NewEvaluableExpression(s.Expr)
@@ -44,6 +52,27 @@ func (s *DSLStrategy) Match(code string, klines []storage.KLine) bool {
 		fmt.Println("dsl parse error:", err)
 		return false
 	}
+	// 其余变量先交给 dslVars（形态等谓词），再按指标名解析：
+	// ma20 / ma60 / ema13 / rsi6 / kdj_k / macd_dif，或 [kdj(9,3,3).j] 这样的完整写法
+	frame := indicator.Cached(klines)
+vars:
+	for _, name := range expr.Vars() {
+		if _, ok := parameters[name]; ok {
+			continue
+		}
+		for _, resolve := range dslVars {
+			if v, ok := resolve(klines, name); ok {
+				parameters[name] = v
+				continue vars
+			}
+		}
+		v, err := frame.Last(name)
+		if err != nil {
+			fmt.Println("dsl variable error:", err)
+			return false
+		}
+		parameters[name] = v
+	}
 	res, err := expr.Evaluate(parameters)
 	if err != nil {
 		fmt.Println("dsl eval error:", err)
@@ -0,0 +1,39 @@
+package strategy
+
+import (
+	"go-stock-analyzer/backend/levels"
+	"go-stock-analyzer/backend/storage"
+)
+
+// levelsVar DSL 支撑阻力谓词与数值：
+//   - near_support / near_resistance：收盘价位于支撑（阻力）区间内或距其 2% 以内
+//   - breaks_resistance / breaks_support：最后一根 K 线收盘突破前一日的阻力上沿（跌破支撑下沿）
+//   - support / resistance：最近的支撑/阻力价位，没有时为 0
+func levelsVar(klines []storage.KLine, name string) (interface{}, bool) {
+	opt := levels.DefaultOptions()
+	switch name {
+	case "breaks_resistance":
+		return levels.BreaksResistance(klines, opt), true
+	case "breaks_support":
+		return levels.BreaksSupport(klines, opt), true
+	case "near_support", "near_resistance", "support", "resistance":
+	default:
+		return nil, false
+	}
+	r := levels.Analyze(klines, opt)
+	switch name {
+	case "near_support":
+		return r.NearSupport(levels.DefaultNearPct), true
+	case "near_resistance":
+		return r.NearResistance(levels.DefaultNearPct), true
+	case "support":
+		if len(r.Supports) > 0 {
+			return r.Supports[0].Price, true
+		}
+	case "resistance":
+		if len(r.Resistances) > 0 {
+			return r.Resistances[0].Price, true
+		}
+	}
+	return 0.0, true
+}
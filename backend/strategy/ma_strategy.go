@@ -1,6 +1,11 @@
 package strategy
 
-import "go-stock-analyzer/backend/storage"
+import (
+	"fmt"
+
+	"go-stock-analyzer/backend/indicator"
+	"go-stock-analyzer/backend/storage"
+)
 
 type MAStrategy struct {
 	MA       int
@@ -17,22 +22,13 @@ func (s *MAStrategy) Match(code string, klines []storage.KLine) bool {
 	if len(klines) < s.HoldDays {
 		return false
 	}
+	ma, err := indicator.Cached(klines).Get(fmt.Sprintf("ma(%d)", s.MA))
+	if err != nil {
+		return false
+	}
 	for i := len(klines) - s.HoldDays; i < len(klines); i++ {
-		k := klines[i]
-		var maValue float64
-		switch s.MA {
-		case 5:
-			maValue = k.MA5
-		case 10:
-			maValue = k.MA10
-		case 20:
-			maValue = k.MA20
-		case 30:
-			maValue = k.MA30
-		default:
-			maValue = k.MA20
-		}
-		if k.Close < maValue {
+		// 均线尚未形成（K 线不足 MA 根）时不满足
+		if ma[i] == 0 || klines[i].Close < ma[i] {
 			return false
 		}
 	}
@@ -1,6 +1,9 @@
 package strategy
 
-import "go-stock-analyzer/backend/storage"
+import (
+	"go-stock-analyzer/backend/indicator"
+	"go-stock-analyzer/backend/storage"
+)
 
 type MACDStrategy struct{}
 
@@ -12,7 +15,9 @@ func (s *MACDStrategy) Match(code string, klines []storage.KLine) bool {
 	if len(klines) < 2 {
 		return false
 	}
-	prev := klines[len(klines)-2]
-	last := klines[len(klines)-1]
-	return prev.DIF < prev.DEA && last.DIF > last.DEA
+	f := indicator.Cached(klines)
+	dif, _ := f.Get("macd.dif")
+	dea, _ := f.Get("macd.dea")
+	n := len(klines) - 1
+	return dif[n-1] < dea[n-1] && dif[n] > dea[n]
 }
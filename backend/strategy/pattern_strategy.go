@@ -0,0 +1,56 @@
+package strategy
+
+import (
+	"strings"
+
+	"go-stock-analyzer/backend/pattern"
+	"go-stock-analyzer/backend/storage"
+)
+
+// DefaultPatterns Pattern 策略未配置 patterns 时使用的看涨形态
+var DefaultPatterns = []string{"hammer", "bullish_engulfing", "morning_star", "three_white_soldiers"}
+
+// PatternStrategy 最近 Lookback 根 K 线内出现任一指定 K 线形态且强度不低于 MinStrength 时入选
+type PatternStrategy struct {
+	Patterns    []string
+	MinStrength float64
+	Lookback    int
+}
+
+func NewPatternStrategy(patterns []string, minStrength float64, lookback int) *PatternStrategy {
+	if len(patterns) == 0 {
+		patterns = DefaultPatterns
+	}
+	if lookback <= 0 {
+		lookback = 1
+	}
+	return &PatternStrategy{Patterns: patterns, MinStrength: minStrength, Lookback: lookback}
+}
+
+func (s *PatternStrategy) Name() string { return "Pattern" }
+
+func (s *PatternStrategy) Match(code string, klines []storage.KLine) bool {
+	for i := len(klines) - 1; i >= 0 && i >= len(klines)-s.Lookback; i-- {
+		for _, h := range pattern.DetectAt(klines, i, s.Patterns...) {
+			if h.Strength >= s.MinStrength {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// patternVar DSL 形态谓词：hammer、morning_star 等为最后一根 K 线是否出现该形态（bool），
+// hammer_strength 等为其强度（0~100，未出现为 0）
+func patternVar(klines []storage.KLine, name string) (interface{}, bool) {
+	if base := strings.TrimSuffix(name, "_strength"); base != name && pattern.Known(base) {
+		if h := pattern.Last(klines, base); h != nil {
+			return h.Strength, true
+		}
+		return 0.0, true
+	}
+	if pattern.Known(name) {
+		return pattern.Last(klines, name) != nil, true
+	}
+	return nil, false
+}
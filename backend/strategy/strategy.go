@@ -2,8 +2,10 @@ package strategy
 
 import (
 	"fmt"
+	"log"
 
 	"go-stock-analyzer/backend/config"
+	"go-stock-analyzer/backend/fetcher"
 	"go-stock-analyzer/backend/storage"
 )
 type Strategy interface {
@@ -54,13 +56,66 @@ func strategyFromConfig(sc config.StrategyConfig) (Strategy, error) {
 			}
 		}
 		return NewDSLStrategy(expr), nil
+	case "Pattern":
+		var names []string
+		if v, ok := sc.Params["patterns"].([]interface{}); ok {
+			for _, n := range v {
+				if s, ok := n.(string); ok {
+					names = append(names, s)
+				}
+			}
+		}
+		minStrength := 0.0
+		switch t := sc.Params["min_strength"].(type) {
+		case int:
+			minStrength = float64(t)
+		case float64:
+			minStrength = t
+		}
+		lookback := 1
+		switch t := sc.Params["lookback"].(type) {
+		case int:
+			lookback = t
+		case float64:
+			lookback = int(t)
+		}
+		return NewPatternStrategy(names, minStrength, lookback), nil
+	case "ChartPattern":
+		var types []string
+		if v, ok := sc.Params["types"].([]interface{}); ok {
+			for _, n := range v {
+				if s, ok := n.(string); ok {
+					types = append(types, s)
+				}
+			}
+		}
+		within := 3
+		switch t := sc.Params["within"].(type) {
+		case int:
+			within = t
+		case float64:
+			within = int(t)
+		}
+		requireVolume := true
+		if v, ok := sc.Params["require_volume"].(bool); ok {
+			requireVolume = v
+		}
+		minStrength := 0.0
+		switch t := sc.Params["min_strength"].(type) {
+		case int:
+			minStrength = float64(t)
+		case float64:
+			minStrength = t
+		}
+		return NewChartPatternStrategy(types, within, requireVolume, minStrength), nil
 	default:
 		return nil, fmt.Errorf("unknown strategy: %s", sc.Name)
 	}
 }
 
 func RunAll(stocks []string) {
-	for _, sc := range config.Cfg.Strategies {
+	seen := make(map[string]bool)
+	for i, sc := range config.Cfg.Strategies {
 		if !sc.Enabled {
 			continue
 		}
@@ -68,14 +123,31 @@ func RunAll(stocks []string) {
 		if err != nil {
 			continue
 		}
+		// 结果按 label / 周期区分，未配置 label 的同名策略再按配置序号区分，避免互相覆盖
+		name := sc.ResultName()
+		if seen[name] {
+			name = fmt.Sprintf("%s#%d", name, i+1)
+		}
+		seen[name] = true
+		if ba, ok := strat.(benchmarkAware); ok {
+			bench := fetcher.DefaultBenchmark()
+			if v, ok := sc.Params["benchmark"].(string); ok && v != "" {
+				bench = v
+			}
+			if bk, err := fetcher.LoadIndexKLines(bench, sc.Period, config.Cfg.KLineDays); err != nil {
+				log.Printf("load benchmark %s error: %v", bench, err)
+			} else {
+				ba.SetBenchmark(bk)
+			}
+		}
 		for _, code := range stocks {
-			klines, err := storage.LoadKLines(code, config.Cfg.KLineDays)
+			klines, err := fetcher.LoadPeriodKLines(code, sc.Period, config.Cfg.KLineDays, config.Cfg.Adjust, "")
 			if err != nil || len(klines) == 0 {
 				continue
 			}
 			if strat.Match(code, klines) {
 				last := klines[len(klines)-1]
-				storage.SaveResult(code, last.Date, strat.Name())
+				storage.SaveResult(code, last.Date, name)
 			}
 		}
 	}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 
+	"go-stock-analyzer/backend/fetcher"
+	"go-stock-analyzer/backend/indicator"
 	"go-stock-analyzer/backend/storage"
 
 	"github.com/traefik/yaegi/interp"
@@ -16,6 +19,7 @@ import (
 type ExecConfig struct {
 	TotalTimeout     time.Duration // 总超时
 	PerSymbolTimeout time.Duration // 每只股票执行超时，可二次控制
+	Benchmark        []storage.KLine // 基准指数 K 线，非空时每根 K 线增加 BenchClose（按日期对齐）
 }
 
 // 默认配置（可修改）
@@ -26,6 +30,8 @@ var DefaultExecConfig = ExecConfig{
 
 // ExecuteStrategy 用用户 code 在 symbols 列表上执行 Match 函数。
 // - code: 用户提供的源码字符串，必须定义 `func Match(symbol string, klines []map[string]interface{}) bool`
+//   每根 K 线包含 Date/Open/High/Low/Close/Volume，配置了基准时另有 BenchClose；
+//   另有技术指标（indicator 包输出名的大写形式，如 RSI6 / KDJ_K / BOLL_UPPER / ADX）
 // - symbols: 如 ["sz000001", "sh600000"]
 // - loadKlines: 由调用方提供加载函数 (symbol, days) -> []storage.KLine
 func ExecuteStrategy(code string, symbols []string, klineDays int, loadKlines func(string, int) ([]storage.KLine, error), cfg ExecConfig) ([]string, error) {
@@ -74,8 +80,13 @@ func ExecuteStrategy(code string, symbols []string, klineDays int, loadKlines fu
 			continue
 		}
 		// convert []storage.KLine -> []map[string]interface{}
+		var bench []storage.KLine
+		if len(cfg.Benchmark) > 0 {
+			bench = fetcher.AlignBenchmark(klines, cfg.Benchmark)
+		}
+		series, _ := indicator.Compute(indicator.FromKLines(klines))
 		arg := make([]map[string]interface{}, 0, len(klines))
-		for _, k := range klines {
+		for idx, k := range klines {
 			m := map[string]interface{}{
 				"Date":  k.Date,
 				"Open":  k.Open,
@@ -84,6 +95,12 @@ func ExecuteStrategy(code string, symbols []string, klineDays int, loadKlines fu
 				"Close": k.Close,
 				"Volume": k.Volume,
 			}
+			if bench != nil {
+				m["BenchClose"] = bench[idx].Close
+			}
+			for name, v := range series {
+				m[strings.ToUpper(name)] = v[idx]
+			}
 			arg = append(arg, m)
 		}
 
@@ -0,0 +1,173 @@
+package tdx
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go-stock-analyzer/backend/storage"
+)
+
+// recordSize 通达信日线文件每条记录 32 字节（小端）：
+//
+//	0  uint32  日期 YYYYMMDD
+//	4  uint32  开盘价 ×100（基金/ETF/债券 ×1000）
+//	8  uint32  最高价
+//	12 uint32  最低价
+//	16 uint32  收盘价
+//	20 float32 成交额（元）
+//	24 uint32  成交量（股）
+//	28 uint32  保留
+const recordSize = 32
+
+type dayRecord struct {
+	Date     uint32
+	Open     uint32
+	High     uint32
+	Low      uint32
+	Close    uint32
+	Amount   float32
+	Volume   uint32
+	Reserved uint32
+}
+
+// PriceScale 返回价格的缩放倍数：基金、ETF、债券为 1000，其余为 100
+func PriceScale(symbol string) float64 {
+	s := strings.ToLower(symbol)
+	if len(s) != 8 {
+		return 100
+	}
+	market, code := s[:2], s[2:]
+	switch market {
+	case "sh":
+		if strings.HasPrefix(code, "5") || strings.HasPrefix(code, "11") || strings.HasPrefix(code, "10") {
+			return 1000
+		}
+	case "sz":
+		if strings.HasPrefix(code, "15") || strings.HasPrefix(code, "16") || strings.HasPrefix(code, "12") {
+			return 1000
+		}
+	}
+	return 100
+}
+
+// ParseDay 解析 .day 文件内容，返回按日期升序的不复权日 K 线（未计算指标）
+func ParseDay(r io.Reader, symbol string) ([]storage.KLine, error) {
+	scale := PriceScale(symbol)
+	out := []storage.KLine{}
+	var rec dayRecord
+	for {
+		err := binary.Read(r, binary.LittleEndian, &rec)
+		if err == io.EOF {
+			break
+		}
+		if err == io.ErrUnexpectedEOF {
+			return nil, fmt.Errorf("tdx: truncated record in %s", symbol)
+		}
+		if err != nil {
+			return nil, err
+		}
+		y, m, d := rec.Date/10000, rec.Date/100%100, rec.Date%100
+		if y < 1990 || m < 1 || m > 12 || d < 1 || d > 31 {
+			return nil, fmt.Errorf("tdx: invalid date %d in %s", rec.Date, symbol)
+		}
+		out = append(out, storage.KLine{
+			Code:   symbol,
+			Date:   fmt.Sprintf("%04d-%02d-%02d", y, m, d),
+			Open:   round(float64(rec.Open) / scale),
+			High:   round(float64(rec.High) / scale),
+			Low:    round(float64(rec.Low) / scale),
+			Close:  round(float64(rec.Close) / scale),
+			Volume: float64(rec.Volume),
+		})
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Date < out[j].Date })
+	return out, nil
+}
+
+// round 保留 3 位小数，去掉除法带来的浮点误差
+func round(v float64) float64 { return math.Round(v*1000) / 1000 }
+
+// vipdoc 兼容传入通达信安装目录或其下的 vipdoc 目录
+func vipdoc(dir string) string {
+	if filepath.Base(filepath.Clean(dir)) == "vipdoc" {
+		return dir
+	}
+	return filepath.Join(dir, "vipdoc")
+}
+
+// DayFilePath 返回 symbol（如 sh600000）对应的 .day 文件路径：{dir}/vipdoc/{sh|sz|bj}/lday/{symbol}.day
+func DayFilePath(dir, symbol string) string {
+	s := strings.ToLower(symbol)
+	market := ""
+	if len(s) > 2 {
+		market = s[:2]
+	}
+	return filepath.Join(vipdoc(dir), market, "lday", s+".day")
+}
+
+// ReadDayFile 读取 symbol 的日线文件
+func ReadDayFile(dir, symbol string) ([]storage.KLine, error) {
+	f, err := os.Open(DayFilePath(dir, symbol))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if fi, err := f.Stat(); err == nil && fi.Size()%recordSize != 0 {
+		return nil, fmt.Errorf("tdx: %s size %d is not a multiple of %d", f.Name(), fi.Size(), recordSize)
+	}
+	return ParseDay(f, strings.ToLower(symbol))
+}
+
+// ListSymbols 列出目录下所有日线文件对应的 symbol（sh/sz/bj）
+func ListSymbols(dir string) ([]string, error) {
+	out := []string{}
+	for _, market := range []string{"sh", "sz", "bj"} {
+		files, err := filepath.Glob(filepath.Join(vipdoc(dir), market, "lday", "*.day"))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			name := strings.ToLower(strings.TrimSuffix(filepath.Base(f), filepath.Ext(f)))
+			if len(name) == 8 && strings.HasPrefix(name, market) {
+				out = append(out, name)
+			}
+		}
+	}
+	if len(out) == 0 {
+		if _, err := os.Stat(vipdoc(dir)); err != nil {
+			return nil, fmt.Errorf("tdx: %w", err)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// WriteDay 把 K 线编码为 .day 格式（用于导出到通达信或生成测试数据）
+func WriteDay(w io.Writer, symbol string, klines []storage.KLine) error {
+	scale := PriceScale(symbol)
+	for _, k := range klines {
+		var y, m, d uint32
+		if _, err := fmt.Sscanf(k.Date, "%04d-%02d-%02d", &y, &m, &d); err != nil {
+			return fmt.Errorf("tdx: invalid date %q", k.Date)
+		}
+		rec := dayRecord{
+			Date:   y*10000 + m*100 + d,
+			Open:   uint32(math.Round(k.Open * scale)),
+			High:   uint32(math.Round(k.High * scale)),
+			Low:    uint32(math.Round(k.Low * scale)),
+			Close:  uint32(math.Round(k.Close * scale)),
+			Amount: float32(k.Close * k.Volume),
+			Volume: uint32(k.Volume),
+		}
+		if err := binary.Write(w, binary.LittleEndian, &rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,252 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen 熔断打开期间直接拒绝请求，避免继续刺激上游
+var ErrCircuitOpen = errors.New("upstream: circuit open")
+
+// StatusError 上游返回非 2xx 状态码
+type StatusError struct {
+	URL  string
+	Code int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("upstream: %s returned status %d", e.URL, e.Code)
+}
+
+// Options 上游访问参数
+type Options struct {
+	Rate             float64       // 全局每秒请求数（令牌桶速率），<=0 表示不限
+	Burst            int           // 令牌桶容量
+	HostConcurrency  int           // 每个 host 的最大并发请求数
+	Retries          int           // 最大尝试次数（含首次）
+	Backoff          time.Duration // 指数退避基数
+	MaxBackoff       time.Duration // 单次退避上限
+	Timeout          time.Duration // 单次请求超时（含读取 body）
+	BreakerThreshold int           // 连续被拒绝多少次后熔断
+	BreakerCooldown  time.Duration // 熔断持续时间
+}
+
+// DefaultOptions 默认参数
+var DefaultOptions = Options{
+	Rate:             5,
+	Burst:            5,
+	HostConcurrency:  4,
+	Retries:          3,
+	Backoff:          500 * time.Millisecond,
+	MaxBackoff:       10 * time.Second,
+	Timeout:          30 * time.Second,
+	BreakerThreshold: 5,
+	BreakerCooldown:  60 * time.Second,
+}
+
+// HostStats 单个 host 的运行状态
+type HostStats struct {
+	InFlight    int       `json:"in_flight"`
+	Requests    int64     `json:"requests"`
+	Failures    int64     `json:"failures"`
+	Rejections  int       `json:"consecutive_rejections"`
+	CircuitOpen bool      `json:"circuit_open"`
+	OpenUntil   time.Time `json:"open_until,omitempty"`
+}
+
+type hostState struct {
+	sem chan struct{}
+
+	mu         sync.Mutex
+	requests   int64
+	failures   int64
+	rejections int
+	openUntil  time.Time
+}
+
+// Client 带全局限速、单 host 并发上限、指数退避重试与熔断的 HTTP 客户端
+type Client struct {
+	opts    Options
+	http    *http.Client
+	limiter *tokenBucket
+
+	mu    sync.Mutex
+	hosts map[string]*hostState
+}
+
+// New 创建客户端，transport 为 nil 时使用 http.DefaultTransport
+func New(opts Options, transport http.RoundTripper) *Client {
+	if opts.Burst <= 0 {
+		opts.Burst = DefaultOptions.Burst
+	}
+	if opts.HostConcurrency <= 0 {
+		opts.HostConcurrency = DefaultOptions.HostConcurrency
+	}
+	if opts.Retries <= 0 {
+		opts.Retries = DefaultOptions.Retries
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = DefaultOptions.Backoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = DefaultOptions.MaxBackoff
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultOptions.Timeout
+	}
+	if opts.BreakerThreshold <= 0 {
+		opts.BreakerThreshold = DefaultOptions.BreakerThreshold
+	}
+	if opts.BreakerCooldown <= 0 {
+		opts.BreakerCooldown = DefaultOptions.BreakerCooldown
+	}
+	return &Client{
+		opts:    opts,
+		http:    &http.Client{Transport: transport},
+		limiter: newTokenBucket(opts.Rate, opts.Burst),
+		hosts:   map[string]*hostState{},
+	}
+}
+
+func (c *Client) host(name string) *hostState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	h, ok := c.hosts[name]
+	if !ok {
+		h = &hostState{sem: make(chan struct{}, c.opts.HostConcurrency)}
+		c.hosts[name] = h
+	}
+	return h
+}
+
+// Stats 返回各 host 的状态快照
+func (c *Client) Stats() map[string]HostStats {
+	c.mu.Lock()
+	hosts := make(map[string]*hostState, len(c.hosts))
+	for k, v := range c.hosts {
+		hosts[k] = v
+	}
+	c.mu.Unlock()
+	now := time.Now()
+	out := make(map[string]HostStats, len(hosts))
+	for name, h := range hosts {
+		h.mu.Lock()
+		st := HostStats{
+			InFlight:   len(h.sem),
+			Requests:   h.requests,
+			Failures:   h.failures,
+			Rejections: h.rejections,
+		}
+		if now.Before(h.openUntil) {
+			st.CircuitOpen = true
+			st.OpenUntil = h.openUntil
+		}
+		h.mu.Unlock()
+		out[name] = st
+	}
+	return out
+}
+
+// isRejection 上游明确拒绝（被限流/封禁）的状态码；新浪封 IP 时返回 403 或 456
+func isRejection(code int) bool {
+	return code == http.StatusForbidden || code == http.StatusTooManyRequests || code == 456
+}
+
+func isRetryable(err error) bool {
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.Code >= 500 || isRejection(se.Code)
+	}
+	return !errors.Is(err, ErrCircuitOpen)
+}
+
+// Get 发起 GET 请求并返回完整 body；非 2xx、网络错误按配置退避重试
+func (c *Client) Get(url string, header map[string]string) ([]byte, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header.Add(k, v)
+	}
+	h := c.host(req.URL.Host)
+	var lastErr error
+	for attempt := 1; attempt <= c.opts.Retries; attempt++ {
+		body, err := c.do(h, req)
+		if err == nil {
+			return body, nil
+		}
+		lastErr = err
+		if !isRetryable(err) || attempt == c.opts.Retries {
+			break
+		}
+		time.Sleep(c.backoff(attempt))
+	}
+	return nil, lastErr
+}
+
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.opts.Backoff << uint(attempt-1)
+	if d <= 0 || d > c.opts.MaxBackoff {
+		d = c.opts.MaxBackoff
+	}
+	// 加一点抖动，避免多个 worker 同时重试
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// do 执行一次请求：熔断检查 -> 全局限速 -> host 并发槽 -> 请求并读取 body
+func (c *Client) do(h *hostState, req *http.Request) ([]byte, error) {
+	h.mu.Lock()
+	open := time.Now().Before(h.openUntil)
+	h.mu.Unlock()
+	if open {
+		return nil, ErrCircuitOpen
+	}
+	c.limiter.Wait()
+	h.sem <- struct{}{}
+	defer func() { <-h.sem }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.opts.Timeout)
+	defer cancel()
+	resp, err := c.http.Do(req.Clone(ctx))
+	var body []byte
+	if err == nil {
+		body, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err == nil && (resp.StatusCode < 200 || resp.StatusCode > 299) {
+			err = &StatusError{URL: req.URL.String(), Code: resp.StatusCode}
+		}
+	}
+	c.record(h, req.URL.Host, err)
+	if err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// record 更新统计与熔断状态：连续被拒绝达到阈值后熔断 BreakerCooldown
+func (c *Client) record(h *hostState, host string, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.requests++
+	if err == nil {
+		h.rejections = 0
+		return
+	}
+	h.failures++
+	var se *StatusError
+	if errors.As(err, &se) && isRejection(se.Code) {
+		h.rejections++
+		if h.rejections >= c.opts.BreakerThreshold {
+			h.openUntil = time.Now().Add(c.opts.BreakerCooldown)
+			log.Printf("upstream: %s rejected %d requests in a row (status %d), circuit open until %s", host, h.rejections, se.Code, h.openUntil.Format("15:04:05"))
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package upstream
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"go-stock-analyzer/backend/config"
+)
+
+var (
+	defaultOnce   sync.Once
+	defaultClient *Client
+)
+
+// OptionsFromConfig 从 config.yaml 的 upstream_* 读取参数，未配置的项使用默认值；
+// 兼容旧的 worker_retries / worker_backoff_ms 配置。
+func OptionsFromConfig() Options {
+	cfg := config.Cfg
+	opts := DefaultOptions
+	if cfg.UpstreamRate > 0 {
+		opts.Rate = cfg.UpstreamRate
+	}
+	if cfg.UpstreamBurst > 0 {
+		opts.Burst = cfg.UpstreamBurst
+	}
+	if cfg.UpstreamHostConcurrency > 0 {
+		opts.HostConcurrency = cfg.UpstreamHostConcurrency
+	}
+	if cfg.UpstreamRetries > 0 {
+		opts.Retries = cfg.UpstreamRetries
+	} else if cfg.WorkerRetries > 0 {
+		opts.Retries = cfg.WorkerRetries
+	}
+	if cfg.UpstreamBackoffMs > 0 {
+		opts.Backoff = time.Duration(cfg.UpstreamBackoffMs) * time.Millisecond
+	} else if cfg.WorkerBackoffMs > 0 {
+		opts.Backoff = time.Duration(cfg.WorkerBackoffMs) * time.Millisecond
+	}
+	if cfg.UpstreamTimeoutMs > 0 {
+		opts.Timeout = time.Duration(cfg.UpstreamTimeoutMs) * time.Millisecond
+	}
+	if cfg.UpstreamBreakerThreshold > 0 {
+		opts.BreakerThreshold = cfg.UpstreamBreakerThreshold
+	}
+	if cfg.UpstreamBreakerCooldownS > 0 {
+		opts.BreakerCooldown = time.Duration(cfg.UpstreamBreakerCooldownS) * time.Second
+	}
+	return opts
+}
+
+// Init 用指定 transport 初始化全局客户端，需在第一次调用 Default 之前执行
+func Init(transport http.RoundTripper) {
+	defaultOnce.Do(func() {
+		defaultClient = New(OptionsFromConfig(), transport)
+	})
+}
+
+// Default 返回全局共享客户端，所有访问上游行情接口的代码都应使用它
+func Default() *Client {
+	Init(nil)
+	return defaultClient
+}
@@ -0,0 +1,217 @@
+package watchlist
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"go-stock-analyzer/backend/datasource"
+	"go-stock-analyzer/backend/storage"
+)
+
+// 支持的自选股文件格式
+const (
+	FormatBLK = "blk" // 通达信自定义板块 T0002/blocknew/*.blk：每行「市场位+6 位代码」，1=沪 0=深 2=北
+	FormatEBK = "ebk" // 东方财富/通达信导出的 .EBK：格式同 blk，首行为空，北交所市场位为 0
+	FormatTHS = "ths" // 同花顺自选股导出：文本，每行一个代码（可带 SH/SZ/BJ 前缀），可有表头与其他列
+	FormatCSV = "csv" // symbol,name
+)
+
+// Formats 返回支持的格式
+func Formats() []string { return []string{FormatBLK, FormatEBK, FormatTHS, FormatCSV} }
+
+// DetectFormat 根据文件扩展名推断格式，无法判断时返回空串
+func DetectFormat(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".blk":
+		return FormatBLK
+	case ".ebk":
+		return FormatEBK
+	case ".csv":
+		return FormatCSV
+	case ".txt", ".xls", ".sel":
+		return FormatTHS
+	}
+	return ""
+}
+
+// Entry 自选股条目
+type Entry struct {
+	Symbol string `json:"symbol"`
+	Name   string `json:"name"`
+}
+
+// Parse 解析自选股文件，返回规范化后的 symbol（去重，保持文件中的顺序）以及无法识别的原始行
+func Parse(format string, data []byte) ([]Entry, []string, error) {
+	var entries []Entry
+	var bad []string
+	seen := map[string]bool{}
+	add := func(sym, name, raw string) {
+		if sym == "" {
+			bad = append(bad, raw)
+			return
+		}
+		if !seen[sym] {
+			seen[sym] = true
+			entries = append(entries, Entry{Symbol: sym, Name: name})
+		}
+	}
+	switch format {
+	case FormatBLK, FormatEBK:
+		sc := bufio.NewScanner(bytes.NewReader(data))
+		for sc.Scan() {
+			line := strings.TrimSpace(sc.Text())
+			if line == "" {
+				continue
+			}
+			add(fromTDXCode(line), "", line)
+		}
+		return entries, bad, sc.Err()
+	case FormatTHS:
+		sc := bufio.NewScanner(bytes.NewReader(data))
+		for sc.Scan() {
+			line := strings.TrimSpace(sc.Text())
+			if line == "" {
+				continue
+			}
+			// 第一列为代码，列之间以制表符、逗号或空白分隔；表头（代码/名称）等非代码行跳过
+			fields := strings.FieldsFunc(line, func(r rune) bool { return r == '\t' || r == ',' || r == ' ' })
+			if len(fields) == 0 {
+				continue
+			}
+			first := strings.Trim(fields[0], `"=`)
+			if !hasDigits(first) {
+				continue
+			}
+			name := ""
+			if len(fields) > 1 && !hasDigits(fields[1]) {
+				name = strings.Trim(fields[1], `"`)
+			}
+			add(datasource.NormalizeSymbol(first), name, line)
+		}
+		return entries, bad, sc.Err()
+	case FormatCSV:
+		rows, err := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))).ReadAll()
+		if err != nil {
+			return nil, nil, err
+		}
+		for i, row := range rows {
+			if len(row) == 0 || strings.TrimSpace(row[0]) == "" {
+				continue
+			}
+			if i == 0 && !hasDigits(row[0]) {
+				continue // 表头
+			}
+			name := ""
+			if len(row) > 1 {
+				name = strings.TrimSpace(row[1])
+			}
+			add(datasource.NormalizeSymbol(row[0]), name, strings.Join(row, ","))
+		}
+		return entries, bad, nil
+	}
+	return nil, nil, fmt.Errorf("unsupported format: %s", format)
+}
+
+// fromTDXCode 把 1600000 / 0000001 / 2830799 转为 sh600000 / sz000001 / bj830799
+func fromTDXCode(s string) string {
+	if len(s) != 7 || !hasOnlyDigits(s) {
+		return datasource.NormalizeSymbol(s)
+	}
+	code := s[1:]
+	switch s[0] {
+	case '1':
+		return "sh" + code
+	case '2':
+		return "bj" + code
+	case '0':
+		// EBK 中北交所的市场位也是 0
+		if datasource.MarketOf(code) == "bj" {
+			return "bj" + code
+		}
+		return "sz" + code
+	}
+	return ""
+}
+
+// toTDXCode 把 symbol 转为通达信/东方财富的「市场位+代码」
+func toTDXCode(symbol, format string) string {
+	code := datasource.BareCode(symbol)
+	switch {
+	case strings.HasPrefix(symbol, "sh"):
+		return "1" + code
+	case strings.HasPrefix(symbol, "bj") && format == FormatBLK:
+		return "2" + code
+	default:
+		return "0" + code
+	}
+}
+
+// Format 把自选股编码为指定格式
+func Format(format string, entries []Entry) ([]byte, error) {
+	var buf bytes.Buffer
+	switch format {
+	case FormatBLK:
+		for _, e := range entries {
+			buf.WriteString(toTDXCode(e.Symbol, format) + "\r\n")
+		}
+	case FormatEBK:
+		buf.WriteString("\r\n")
+		for _, e := range entries {
+			buf.WriteString(toTDXCode(e.Symbol, format) + "\r\n")
+		}
+	case FormatTHS:
+		// 同花顺「导入自选股」识别每行一个带市场前缀的代码
+		for _, e := range entries {
+			buf.WriteString(strings.ToUpper(e.Symbol) + "\r\n")
+		}
+	case FormatCSV:
+		w := csv.NewWriter(&buf)
+		_ = w.Write([]string{"symbol", "name"})
+		for _, e := range entries {
+			_ = w.Write([]string{e.Symbol, e.Name})
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported format: %s", format)
+	}
+	return buf.Bytes(), nil
+}
+
+// ResolveNames 用证券主表补全名称，返回库中查不到的 symbol
+func ResolveNames(entries []Entry) []string {
+	unknown := []string{}
+	for i := range entries {
+		st, err := storage.GetStock(entries[i].Symbol)
+		if err != nil || st == nil {
+			unknown = append(unknown, entries[i].Symbol)
+			continue
+		}
+		entries[i].Name = st.Name
+	}
+	return unknown
+}
+
+func hasDigits(s string) bool {
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			return true
+		}
+	}
+	return false
+}
+
+func hasOnlyDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,71 @@
+package web
+
+import (
+	"net/http"
+	"time"
+
+	"go-stock-analyzer/backend/calendar"
+	"go-stock-analyzer/backend/clock"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GET /api/calendar?from=2024-01-01&to=2024-12-31
+// 返回区间内的交易日与休市日，默认当前自然月
+func GetCalendarHandler(c *gin.Context) {
+	now := clock.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, clock.Shanghai)
+	to := from.AddDate(0, 1, -1)
+	var err error
+	if v := c.Query("from"); v != "" {
+		if from, err = clock.ParseDate(v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from"})
+			return
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if to, err = clock.ParseDate(v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to"})
+			return
+		}
+	}
+	if to.Before(from) || to.Sub(from) > 366*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "range must be within one year"})
+		return
+	}
+	days := calendar.TradingDays(from, to)
+	holidays := []string{}
+	for _, d := range calendar.Holidays() {
+		if d >= from.Format("2006-01-02") && d <= to.Format("2006-01-02") {
+			holidays = append(holidays, d)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"from": from.Format("2006-01-02"), "to": to.Format("2006-01-02"), "trading_days": days, "holidays": holidays})
+}
+
+// GET /api/clock 当前市场时间（Asia/Shanghai）与时钟模式
+func GetClockHandler(c *gin.Context) {
+	now := clock.Now()
+	resp := gin.H{"now": now.Format("2006-01-02 15:04:05"), "mode": "real", "phase": calendar.PhaseAt(now)}
+	if sc, ok := clock.Current().(*clock.SimClock); ok {
+		resp["mode"] = "sim"
+		resp["speed"] = sc.Speed()
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// POST /api/clock/advance?d=30m 模拟时钟向前拨动（仅 sim 模式）
+func AdvanceClockHandler(c *gin.Context) {
+	sc, ok := clock.Current().(*clock.SimClock)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "clock is not simulated"})
+		return
+	}
+	d, err := time.ParseDuration(c.Query("d"))
+	if err != nil || d <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid duration"})
+		return
+	}
+	sc.Advance(d)
+	c.JSON(http.StatusOK, gin.H{"now": sc.Now().Format("2006-01-02 15:04:05")})
+}
@@ -0,0 +1,138 @@
+package web
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-stock-analyzer/backend/fetcher"
+	"go-stock-analyzer/backend/klinecsv"
+	"go-stock-analyzer/backend/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GET /api/kline/export?symbol=sz000001&days=0&adjust=&period=day&indicators=1
+// 批量导出：symbols=sz000001,sh600000 或 target=watchlist|all|board:创业板，输出首列为 Symbol。
+// days 为 0 时导出库中全部数据；列格式与 predict/data/stock_history.csv 一致（Date,Open,High,Low,Close,Volume）
+func ExportKLineHandler(c *gin.Context) {
+	symbols := []string{}
+	bulk := false
+	if s := strings.TrimSpace(c.Query("symbol")); s != "" {
+		symbols = append(symbols, s)
+	} else if s := strings.TrimSpace(c.Query("symbols")); s != "" {
+		symbols = strings.Split(s, ",")
+		bulk = true
+	} else if target := c.Query("target"); target != "" {
+		var err error
+		if symbols, err = targetSymbols(target); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		bulk = true
+	} else {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "symbol, symbols or target required"})
+		return
+	}
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "0"))
+	if days <= 0 {
+		days = -1
+	}
+	adjust := c.Query("adjust")
+	if !fetcher.ValidAdjust(adjust) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid adjust"})
+		return
+	}
+	period := c.DefaultQuery("period", fetcher.PeriodDay)
+	if !fetcher.ValidPeriod(period) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid period"})
+		return
+	}
+	indicators, _ := strconv.ParseBool(c.DefaultQuery("indicators", "false"))
+
+	name := "klines.csv"
+	if !bulk {
+		name = symbols[0] + ".csv"
+	}
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	w := klinecsv.NewWriter(c.Writer, klinecsv.WriteOptions{Indicators: indicators, Symbol: bulk})
+	for _, sym := range symbols {
+		sym = strings.TrimSpace(sym)
+		klines, err := fetcher.LoadKLinesAdjusted(sym, days, adjust)
+		if err != nil || len(klines) == 0 {
+			continue
+		}
+		if klines, err = fetcher.AggregateKLines(klines, period); err != nil {
+			continue
+		}
+		if err := w.Write(sym, klines); err != nil {
+			// 已开始写出响应，只能中断
+			return
+		}
+	}
+}
+
+// POST /api/kline/import?symbol=sz000001&replace=false
+// 请求体为 CSV（multipart 字段 file 或直接作为 body）。含 Symbol 列时按列中的代码分别导入，否则导入到 symbol。
+// 日期支持 2023-01-01、2023/1/1、20230101；导入后在完整序列上重新计算指标。
+func ImportKLineHandler(c *gin.Context) {
+	var r io.Reader = c.Request.Body
+	if file, err := c.FormFile("file"); err == nil {
+		f, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		defer f.Close()
+		r = f
+	}
+	replace, _ := strconv.ParseBool(c.DefaultQuery("replace", "false"))
+	groups, err := klinecsv.Read(r, strings.TrimSpace(c.Query("symbol")))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	imported := map[string]int{}
+	errs := map[string]string{}
+	for sym, klines := range groups {
+		norm, n, err := fetcher.ImportKLines(sym, klines, replace)
+		if err != nil {
+			errs[sym] = err.Error()
+			continue
+		}
+		imported[norm] = n
+	}
+	c.JSON(http.StatusOK, gin.H{"imported": imported, "errors": errs})
+}
+
+// targetSymbols 解析 watchlist | all | board:xxx
+func targetSymbols(target string) ([]string, error) {
+	out := []string{}
+	if target == "watchlist" {
+		wl, err := storage.GetWatchlist()
+		if err != nil {
+			return nil, err
+		}
+		for _, w := range wl {
+			out = append(out, w.Symbol)
+		}
+		return out, nil
+	}
+	f := storage.StockFilter{}
+	if strings.HasPrefix(target, "board:") {
+		f.Board = strings.TrimPrefix(target, "board:")
+	} else if target != "all" {
+		return strings.Split(target, ","), nil
+	}
+	list, _, err := storage.QueryStocksFilter(f, 0, 1000000)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range list {
+		out = append(out, s.Symbol)
+	}
+	return out, nil
+}
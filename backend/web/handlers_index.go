@@ -0,0 +1,83 @@
+package web
+
+import (
+	"net/http"
+	"strconv"
+
+	"go-stock-analyzer/backend/config"
+	"go-stock-analyzer/backend/fetcher"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GET /api/index/list 基准指数列表
+func ListIndexHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"list": fetcher.ListBenchmarks()})
+}
+
+// GET /api/index/kline?symbol=sh000300&datalen=120&period=day
+// symbol 为空时使用默认基准；指数不复权，指标列与个股一致
+func GetIndexKLineHandler(c *gin.Context) {
+	symbol := c.DefaultQuery("symbol", fetcher.DefaultBenchmark())
+	datalen, _ := strconv.Atoi(c.DefaultQuery("datalen", "120"))
+	if datalen <= 0 {
+		datalen = 120
+	}
+	period := c.DefaultQuery("period", fetcher.PeriodDay)
+	if !fetcher.ValidPeriod(period) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid period"})
+		return
+	}
+	klines, err := fetcher.LoadIndexKLines(symbol, period, datalen)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, klines)
+}
+
+// GET /api/index/compare?symbol=sz000001&index=sh000300&datalen=120&adjust=qfq
+// 个股相对基准的区间收益、超额收益、Beta、相关系数与归一化净值序列
+func CompareIndexHandler(c *gin.Context) {
+	symbol := c.Query("symbol")
+	if symbol == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "symbol required"})
+		return
+	}
+	index := c.DefaultQuery("index", fetcher.DefaultBenchmark())
+	datalen, _ := strconv.Atoi(c.DefaultQuery("datalen", "120"))
+	if datalen <= 0 {
+		datalen = 120
+	}
+	adjust := c.DefaultQuery("adjust", config.Cfg.Adjust)
+	if !fetcher.ValidAdjust(adjust) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid adjust"})
+		return
+	}
+	klines, err := fetcher.LoadKLinesAdjusted(symbol, datalen, adjust)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(klines) < datalen {
+		if _, err := fetcher.SyncKLine(symbol, datalen); err == nil {
+			klines, err = fetcher.LoadKLinesAdjusted(symbol, datalen, adjust)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+	}
+	// 多取一些基准数据，保证个股首日之前有可对齐的基准 K 线
+	bench, err := fetcher.LoadIndexKLines(index, fetcher.PeriodDay, datalen+20)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	report, err := fetcher.CompareBenchmark(symbol, klines, index, bench)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,23 @@
+package web
+
+import (
+	"net/http"
+
+	"go-stock-analyzer/backend/indicator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GET /api/indicators 可用的指标定义（名称、默认参数、输出）与指标组，
+// 供 /api/kline?indicators= 与 DSL 变量参考
+func ListIndicatorsHandler(c *gin.Context) {
+	defs := []gin.H{}
+	for _, d := range indicator.Defs() {
+		defs = append(defs, gin.H{"name": d.Name, "params": d.Params, "outputs": d.Outputs})
+	}
+	groups := gin.H{}
+	for _, g := range indicator.Names() {
+		groups[g] = indicator.Outputs(g)
+	}
+	c.JSON(http.StatusOK, gin.H{"indicators": defs, "groups": groups})
+}
@@ -0,0 +1,71 @@
+package web
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"go-stock-analyzer/backend/config"
+	"go-stock-analyzer/backend/jobs"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GET /api/jobs 所有后台任务的进度
+func ListJobsHandler(c *gin.Context) {
+	list, err := jobs.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"list": list})
+}
+
+// GET /api/jobs/:name/status 任务进度：总数、完成、失败、待处理、处理速度与预计剩余时间
+func JobStatusHandler(c *gin.Context) {
+	st, err := jobs.GetStatus(c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, st)
+}
+
+// POST /api/jobs/:name/start 启动任务
+// body: { "board": "", "sec_type": "stock", "symbols": [], "days": 300, "concurrency": 5, "restart": false }
+// 上次运行未完成时默认从检查点续跑（沿用原参数），restart=true 则按新参数重新开始
+// recompute 任务：symbols 为空时处理 kline 表中全部代码，dry_run=true 只统计指标不一致的行数；dry_run 与上次不同时总是重新开始
+func StartJobHandler(c *gin.Context) {
+	var body struct {
+		jobs.Params
+		Restart bool `json:"restart"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	if body.Concurrency <= 0 {
+		body.Concurrency = config.Cfg.WorkerConcurrency
+	}
+	st, err := jobs.Start(c.Param("name"), body.Params, body.Restart)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, st)
+}
+
+// POST /api/jobs/:name/stop 停止任务（正在处理的证券完成后退出，可再次 start 续跑）
+func StopJobHandler(c *gin.Context) {
+	name := c.Param("name")
+	if err := jobs.Stop(name); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	st, err := jobs.GetStatus(name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, st)
+}
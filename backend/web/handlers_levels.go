@@ -0,0 +1,58 @@
+package web
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-stock-analyzer/backend/config"
+	"go-stock-analyzer/backend/fetcher"
+	"go-stock-analyzer/backend/levels"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GET /api/levels?symbol=sz000001&days=500&adjust=qfq&max=5
+// 基于库中日线的支撑/阻力区间（波段点聚类、成交密集区、未回补缺口），supports / resistances 均由近到远；
+// adjust 默认使用配置中的复权方式
+func GetLevelsHandler(c *gin.Context) {
+	symbol := strings.TrimSpace(c.Query("symbol"))
+	if symbol == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "symbol required"})
+		return
+	}
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "500"))
+	if days <= 0 {
+		days = 500
+	}
+	adjust := c.DefaultQuery("adjust", config.Cfg.Adjust)
+	if !fetcher.ValidAdjust(adjust) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid adjust"})
+		return
+	}
+	opt := levels.DefaultOptions()
+	if m, err := strconv.Atoi(c.Query("max")); err == nil && m > 0 {
+		opt.MaxZones = m
+	}
+	klines, err := fetcher.LoadKLinesAdjusted(symbol, days, adjust)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(klines) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no stored kline for " + symbol})
+		return
+	}
+	res := levels.Analyze(klines, opt)
+	c.JSON(http.StatusOK, gin.H{
+		"symbol":            symbol,
+		"date":              res.Date,
+		"close":             res.Close,
+		"supports":          res.Supports,
+		"resistances":       res.Resistances,
+		"near_support":      res.NearSupport(levels.DefaultNearPct),
+		"near_resistance":   res.NearResistance(levels.DefaultNearPct),
+		"breaks_resistance": levels.BreaksResistance(klines, opt),
+		"breaks_support":    levels.BreaksSupport(klines, opt),
+	})
+}
@@ -0,0 +1,85 @@
+package web
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"go-stock-analyzer/backend/config"
+	"go-stock-analyzer/backend/fetcher"
+	"go-stock-analyzer/backend/storage"
+)
+
+// GET /api/minute_kline?symbol=sz000001&scale=5&start=2025-09-01&end=2025-09-30&limit=
+// start/end 可为日期或 "2006-01-02 15:04:05"，均可省略；limit 为区间内最近条数（默认不限）
+func GetMinuteKLineHandler(c *gin.Context) {
+	symbol := c.Query("symbol")
+	if symbol == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "symbol required"})
+		return
+	}
+	scale, _ := strconv.Atoi(c.DefaultQuery("scale", "5"))
+	if err := fetcher.CheckMinuteScale(scale); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	klines, err := storage.LoadMinuteKLines(symbol, scale, strings.TrimSpace(c.Query("start")), strings.TrimSpace(c.Query("end")), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, klines)
+}
+
+// POST /api/minute_kline/sync 立即抓取分钟 K 线
+// body: { "symbols": optional, 默认自选股; "scales": optional, 默认 config.minute_scales }
+func SyncMinuteKLineHandler(c *gin.Context) {
+	var body struct {
+		Symbols []string `json:"symbols"`
+		Scales  []int    `json:"scales"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	symbols := body.Symbols
+	if len(symbols) == 0 {
+		wl, _ := storage.GetWatchlist()
+		for _, w := range wl {
+			symbols = append(symbols, w.Symbol)
+		}
+	}
+	scales := body.Scales
+	if len(scales) == 0 {
+		scales = config.Cfg.MinuteScales
+	}
+	for _, scale := range scales {
+		if err := fetcher.CheckMinuteScale(scale); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	datalen := config.Cfg.MinuteKLineLen
+	if datalen <= 0 {
+		datalen = 240
+	}
+	saved := map[string]int{}
+	errs := map[string]string{}
+	for _, sym := range symbols {
+		for _, scale := range scales {
+			key := sym + ":" + strconv.Itoa(scale)
+			n, err := fetcher.SyncMinuteKLine(sym, scale, datalen)
+			if err != nil {
+				errs[key] = err.Error()
+				continue
+			}
+			saved[key] = n
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"saved": saved, "errors": errs})
+}
@@ -0,0 +1,69 @@
+package web
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-stock-analyzer/backend/config"
+	"go-stock-analyzer/backend/fetcher"
+	"go-stock-analyzer/backend/pattern"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GET /api/chart_patterns?symbol=sz000001&days=250&types=double_bottom,bull_flag&adjust=qfq&window=5
+// 基于库中日线识别图表形态，返回波段点（swings）与形态（patterns，含关键价位 levels 与叠加线 lines），
+// 下标对应返回的 klines；adjust 默认使用配置中的复权方式
+func GetChartPatternsHandler(c *gin.Context) {
+	symbol := strings.TrimSpace(c.Query("symbol"))
+	if symbol == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "symbol required"})
+		return
+	}
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "250"))
+	if days <= 0 {
+		days = 250
+	}
+	adjust := c.DefaultQuery("adjust", config.Cfg.Adjust)
+	if !fetcher.ValidAdjust(adjust) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid adjust"})
+		return
+	}
+	want := map[string]bool{}
+	if v := c.Query("types"); v != "" {
+		for _, t := range strings.Split(v, ",") {
+			t = strings.TrimSpace(t)
+			if !pattern.IsChartType(t) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "unknown pattern type: " + t, "types": pattern.ChartTypes()})
+				return
+			}
+			want[t] = true
+		}
+	}
+	opt := pattern.DefaultChartOptions()
+	if w, err := strconv.Atoi(c.Query("window")); err == nil && w > 0 {
+		opt.SwingWindow = w
+	}
+	klines, err := fetcher.LoadKLinesAdjusted(symbol, days, adjust)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(klines) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no stored kline for " + symbol})
+		return
+	}
+	patterns := []pattern.ChartPattern{}
+	for _, p := range pattern.DetectChart(klines, opt) {
+		if len(want) == 0 || want[p.Type] {
+			patterns = append(patterns, p)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"symbol":   symbol,
+		"klines":   klines,
+		"swings":   pattern.Swings(klines, opt.SwingWindow),
+		"patterns": patterns,
+	})
+}
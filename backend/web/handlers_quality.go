@@ -0,0 +1,64 @@
+package web
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-stock-analyzer/backend/quality"
+	"go-stock-analyzer/backend/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GET /api/data_quality?symbol=&type=&severity=&page=&size=
+// 返回按证券汇总的问题数（summary）以及分页的问题明细（list）
+// type: ohlc_inconsistent | non_positive_price | duplicate_date | non_trading_day | missing_day | extreme_jump | calendar_unavailable
+func GetDataQualityHandler(c *gin.Context) {
+	symbol := strings.TrimSpace(c.Query("symbol"))
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "100"))
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > 1000 {
+		size = 100
+	}
+	summary, err := storage.SummarizeKLineIssues(symbol)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	list, total, err := storage.QueryKLineIssues(symbol, strings.TrimSpace(c.Query("type")), strings.TrimSpace(c.Query("severity")), (page-1)*size, size)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"summary": summary, "total": total, "list": list})
+}
+
+// POST /api/data_quality/check 立即校验
+// body: { "symbols": ["sz000001"] }，为空时校验全部自选股
+func CheckDataQualityHandler(c *gin.Context) {
+	var body struct {
+		Symbols []string `json:"symbols"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	symbols := body.Symbols
+	if len(symbols) == 0 {
+		wl, err := storage.GetWatchlist()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		for _, w := range wl {
+			symbols = append(symbols, w.Symbol)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"checked": quality.CheckSymbols(symbols)})
+}
@@ -1,25 +1,42 @@
 package web
 
 import (
+	"go-stock-analyzer/backend/calendar"
+	"go-stock-analyzer/backend/clock"
+	"go-stock-analyzer/backend/config"
 	"go-stock-analyzer/backend/fetcher"
+	"go-stock-analyzer/backend/indicator"
+	"go-stock-analyzer/backend/pattern"
 	"go-stock-analyzer/backend/realtime"
 	"go-stock-analyzer/backend/storage"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 // 查询是否开始交易
 // GET /api/is_market_open?code=sz000001
+// 返回 is_open 以及当前交易时段 phase（closed/pre_open/call_auction/morning/lunch/afternoon/close_auction）
 func IsMarketOpenHandler(c *gin.Context) {
 	code := strings.TrimSpace(c.Query("code"))
 	open := realtime.IsMarketOpen(code)
-	c.JSON(http.StatusOK, gin.H{"is_open": open})
+	now := clock.Now()
+	c.JSON(http.StatusOK, gin.H{
+		"is_open":          open,
+		"phase":            calendar.PhaseAt(now),
+		"is_trading_day":   calendar.IsTradingDay(now),
+		"next_trading_day": calendar.NextTradingDay(now).Format("2006-01-02"),
+		"prev_trading_day": calendar.PrevTradingDay(now).Format("2006-01-02"),
+	})
 }
 
-// GET /api/stocks?q=&board=&page=&size=
+// GET /api/stocks?q=&board=&type=&exchange=&st=&suspended=&listed_after=&listed_before=&page=&size=
+// type: stock | etf | fund | index（未指定 type 与 board 时只返回 A 股）；exchange: SSE | SZSE | BSE
+// st / suspended: true | false；listed_after / listed_before: 上市日期区间 YYYY-MM-DD（上市日期未知的证券不参与该过滤）
 func GetStocksHandler(c *gin.Context) {
 	q := strings.TrimSpace(c.Query("q"))
 	board := strings.TrimSpace(c.Query("board"))
@@ -34,7 +51,48 @@ func GetStocksHandler(c *gin.Context) {
 		size = 50
 	}
 	offset := (page - 1) * size
-	list, total, err := storage.QueryStocks(q, board, offset, size)
+	filter := storage.StockFilter{
+		Keyword:      q,
+		Board:        board,
+		SecType:      strings.TrimSpace(c.Query("type")),
+		Exchange:     strings.ToUpper(strings.TrimSpace(c.Query("exchange"))),
+		ListedAfter:  strings.TrimSpace(c.Query("listed_after")),
+		ListedBefore: strings.TrimSpace(c.Query("listed_before")),
+	}
+	for param, dst := range map[string]**bool{"st": &filter.ST, "suspended": &filter.Suspended} {
+		if v := strings.TrimSpace(c.Query(param)); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + param})
+				return
+			}
+			*dst = &b
+		}
+	}
+	list, total, err := storage.QueryStocksFilter(filter, offset, size)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "list": list})
+}
+
+// GET /api/stock_changes?symbol=&type=&since=&page=&size=
+// type: new | delisted | relisted | renamed | board_moved；since: 起始日期 YYYY-MM-DD
+func GetStockChangesHandler(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > 500 {
+		size = 50
+	}
+	list, total, err := storage.QueryStockChanges(
+		strings.TrimSpace(c.Query("symbol")),
+		strings.TrimSpace(c.Query("type")),
+		strings.TrimSpace(c.Query("since")),
+		(page-1)*size, size)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -42,6 +100,21 @@ func GetStocksHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"total": total, "list": list})
 }
 
+// GET /api/universe?date=2024-01-02&type=stock 指定日期在市的证券（含之后已退市的）
+func GetUniverseHandler(c *gin.Context) {
+	date := strings.TrimSpace(c.Query("date"))
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date required (YYYY-MM-DD)"})
+		return
+	}
+	list, err := storage.UniverseAt(date, strings.TrimSpace(c.Query("type")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"date": date, "total": len(list), "list": list})
+}
+
 // Watchlist handlers
 func GetWatchlistHandler(c *gin.Context) {
 	list, err := storage.GetWatchlist()
@@ -85,7 +158,12 @@ func RemoveWatchlistHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"msg": "removed"})
 }
 
-// GET /api/kline?symbol=sz000001&datalen=120
+// GET /api/kline?symbol=sz000001&datalen=120&adjust=qfq&period=week
+// adjust: 空（不复权，默认）| qfq（前复权）| hfq（后复权）
+// period: day（默认）| week | month，周/月线由日线聚合，datalen 为周期 K 线根数
+// indicators: 可选，逗号分隔的指标组（ma,macd,rsi,kdj,boll,atr,obv,cci,wr,dmi,bias,psy,vr,trix）或指标名（ma(60),ema(13),kdj(9,3,3).j），
+// patterns: 可选，1 表示全部 K 线形态，或逗号分隔的形态名（hammer,bullish_engulfing,...），结果为 patterns 数组（index 对应 klines 下标）
+// 指定任一项时返回 {"klines": [...], "indicators": {"rsi6": [...], "ma(60)": [...]}, "patterns": [...]}，否则仍返回 K 线数组
 func GetKLineHandler(c *gin.Context) {
 	symbol := c.Query("symbol")
 	if symbol == "" {
@@ -97,6 +175,18 @@ func GetKLineHandler(c *gin.Context) {
 	if datalen <= 0 {
 		datalen = 120
 	}
+	adjust := c.Query("adjust")
+	if !fetcher.ValidAdjust(adjust) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid adjust"})
+		return
+	}
+	period := c.DefaultQuery("period", fetcher.PeriodDay)
+	if !fetcher.ValidPeriod(period) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid period"})
+		return
+	}
+	bars := datalen
+	datalen = fetcher.DailyBarsFor(period, bars)
 	// If symbol is in watchlist (自选股) -> try to load from DB (these are persisted at startup with 300 days)
 	watch, _ := storage.GetWatchlist()
 	isWatch := false
@@ -106,32 +196,90 @@ func GetKLineHandler(c *gin.Context) {
 			break
 		}
 	}
+	var klines []storage.KLine
+	var err error
 	if isWatch {
-		// load from DB; datalen may be <= stored days (we store 300 days at startup)
-		klines, err := storage.LoadKLines(symbol, datalen)
+		// 自选股读库：数据过期时先增量同步（库中没有时按 watchlist_kline_days 全量回补），
+		// 周/月线用库中已有的日线聚合，不足 datalen 时返回已有部分
+		days := config.Cfg.WatchlistKlineDays
+		if days <= 0 {
+			days = 300
+		}
+		if _, err := fetcher.SyncKLineIfStale(symbol, days); err != nil {
+			log.Printf("sync kline %s error: %v", symbol, err)
+		}
+		klines, err = storage.LoadKLines(symbol, datalen)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		// if DB returns fewer than requested (e.g., first time), try fetch and save
-		if len(klines) < datalen {
-			fetched, err := fetcher.FetchKLine(symbol, datalen)
-			if err == nil && len(fetched) > 0 {
-				_ = storage.SaveKLines(symbol, fetched)
-				// return fetched (most up-to-date)
-				c.JSON(http.StatusOK, fetched)
-				return
-			}
+	} else {
+		// Non-watch symbols: fetch on-the-fly from remote and do NOT persist (only return datalen, default 120)
+		klines, err = fetcher.FetchKLine(symbol, datalen)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
 		}
-		c.JSON(http.StatusOK, klines)
+	}
+	klines, err = fetcher.AdjustKLines(symbol, klines, adjust)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-
-	// Non-watch symbols: fetch on-the-fly from remote and do NOT persist (only return datalen, default 120)
-	klines, err := fetcher.FetchKLine(symbol, datalen)
+	klines, err = fetcher.AggregateKLines(klines, period)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	// indicators=rsi,kdj,... 时返回 {klines, indicators}，指标在截断前的完整序列上计算以减少预热期
+	var series map[string][]float64
+	if names := c.Query("indicators"); names != "" {
+		series, err = indicator.Cached(klines).Compute(indicator.SplitNames(names)...)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	// patterns=1（全部）或 patterns=hammer,doji 时附带 K 线形态标注
+	var hits []pattern.Hit
+	if names := c.Query("patterns"); names != "" {
+		var list []string
+		if names != "1" && names != "all" {
+			for _, n := range strings.Split(names, ",") {
+				if !pattern.Known(n) {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "unknown pattern: " + n})
+					return
+				}
+				list = append(list, n)
+			}
+		}
+		hits = pattern.Detect(klines, list...)
+	}
+	cut := 0
+	if len(klines) > bars {
+		cut = len(klines) - bars
+		klines = klines[cut:]
+		for k, v := range series {
+			series[k] = v[cut:]
+		}
+	}
+	if series != nil || hits != nil {
+		resp := gin.H{"klines": klines}
+		if series != nil {
+			resp["indicators"] = series
+		}
+		if hits != nil {
+			visible := []pattern.Hit{}
+			for _, h := range hits {
+				if h.Index >= cut {
+					h.Index -= cut
+					visible = append(visible, h)
+				}
+			}
+			resp["patterns"] = visible
+		}
+		c.JSON(http.StatusOK, resp)
+		return
+	}
 	c.JSON(http.StatusOK, klines)
 }
@@ -1,6 +1,7 @@
 package web
 
 import (
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
@@ -8,6 +9,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"go-stock-analyzer/backend/config"
+	"go-stock-analyzer/backend/fetcher"
 	"go-stock-analyzer/backend/storage"
 	"go-stock-analyzer/backend/strategyexec"
 )
@@ -35,18 +38,31 @@ func SaveStrategyHandler(c *gin.Context) {
 }
 
 // POST /api/strategy/run 运行策略
-// body: { "id": optional, "code": optional, "target": "watchlist"|"board:上证主板"|"all" }
+// body: { "id": optional, "code": optional, "target": "watchlist"|"board:上证主板"|"all", "period": "day"|"week"|"month", "adjust": ""|"qfq"|"hfq", "as_of": optional, "benchmark": optional }
+// as_of（YYYY-MM-DD）：target 为 all / board 时按该日在市的股票池选股（包含之后退市的股票，避免幸存者偏差），
+// 并且所有标的与基准只使用该日及之前的 K 线
 func RunStrategyHandler(c *gin.Context) {
 	var body struct {
-		ID     int64  `json:"id"`
-		Code   string `json:"code"`
-		Target string `json:"target"`
-		Days   int    `json:"days"`
+		ID     int64   `json:"id"`
+		Code   string  `json:"code"`
+		Target string  `json:"target"`
+		Days   int     `json:"days"`
+		Adjust *string `json:"adjust"`
+		Period string  `json:"period"`
+		AsOf   string  `json:"as_of"`
+		// 基准指数，为空时使用 config.benchmark，"none" 表示不加载
+		Benchmark string `json:"benchmark"`
 	}
 	if err := c.ShouldBindJSON(&body); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
 		return
 	}
+	if body.AsOf != "" {
+		if _, err := time.Parse("2006-01-02", body.AsOf); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid as_of"})
+			return
+		}
+	}
 	code := body.Code
 	if body.ID != 0 && code == "" {
 		// load from DB
@@ -68,6 +84,18 @@ func RunStrategyHandler(c *gin.Context) {
 		for _, w := range wl {
 			symbols = append(symbols, w.Symbol)
 		}
+	} else if body.AsOf != "" && (body.Target == "all" || strings.HasPrefix(body.Target, "board:")) {
+		list, err := storage.UniverseAt(body.AsOf, storage.SecTypeStock)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		board := strings.TrimPrefix(body.Target, "board:")
+		for _, s := range list {
+			if body.Target == "all" || s.Board == board {
+				symbols = append(symbols, s.Symbol)
+			}
+		}
 	} else if strings.HasPrefix(body.Target, "board:") {
 		board := strings.TrimPrefix(body.Target, "board:")
 		list, _, _ := storage.QueryStocks("", board, 0, 10000)
@@ -94,12 +122,45 @@ func RunStrategyHandler(c *gin.Context) {
 	if days <= 0 {
 		days = 120
 	}
+	adjust := config.Cfg.Adjust
+	if body.Adjust != nil {
+		adjust = *body.Adjust
+	}
+	if !fetcher.ValidAdjust(adjust) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid adjust"})
+		return
+	}
+	if !fetcher.ValidPeriod(body.Period) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid period"})
+		return
+	}
+	// as_of 时只用当日及之前的 K 线，策略看到的是该时点的行情
 	loader := func(sym string, d int) ([]storage.KLine, error) {
-		return storage.LoadKLines(sym, d)
+		return fetcher.LoadPeriodKLines(sym, body.Period, d, adjust, body.AsOf)
+	}
+
+	execCfg := strategyexec.DefaultExecConfig
+	if body.Benchmark != "none" {
+		bench := body.Benchmark
+		if bench == "" {
+			bench = fetcher.DefaultBenchmark()
+		}
+		var bk []storage.KLine
+		var err error
+		if body.AsOf != "" {
+			bk, err = fetcher.LoadPeriodKLines(bench, body.Period, days, "", body.AsOf)
+		} else {
+			bk, err = fetcher.LoadIndexKLines(bench, body.Period, days)
+		}
+		if err == nil {
+			execCfg.Benchmark = bk
+		} else {
+			log.Printf("load benchmark %s error: %v", bench, err)
+		}
 	}
 
 	start := time.Now()
-	matches, err := strategyexec.ExecuteStrategy(code, symbols, days, loader, strategyexec.DefaultExecConfig)
+	matches, err := strategyexec.ExecuteStrategy(code, symbols, days, loader, execCfg)
 	duration := time.Since(start)
 	// Save run log optionally (if ID provided)
 	if body.ID != 0 {
@@ -0,0 +1,56 @@
+package web
+
+import (
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"go-stock-analyzer/backend/datasource"
+	"go-stock-analyzer/backend/fetcher"
+	"go-stock-analyzer/backend/tdx"
+
+	"github.com/gin-gonic/gin"
+)
+
+// POST /api/tdx/import?symbol=sh600000&replace=false
+// 上传单个通达信 .day 文件（multipart 字段 file 或直接作为 body）并导入；
+// 未指定 symbol 时取上传文件名（如 sh600000.day）。整目录导入使用 POST /api/jobs/tdx_import/start
+func ImportTDXDayHandler(c *gin.Context) {
+	symbol := strings.TrimSpace(c.Query("symbol"))
+	var r io.Reader = c.Request.Body
+	if file, err := c.FormFile("file"); err == nil {
+		f, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		defer f.Close()
+		r = f
+		if symbol == "" {
+			symbol = strings.TrimSuffix(filepath.Base(file.Filename), filepath.Ext(file.Filename))
+		}
+	}
+	symbol = datasource.NormalizeSymbol(symbol)
+	if symbol == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "symbol required"})
+		return
+	}
+	klines, err := tdx.ParseDay(r, symbol)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	replace, _ := strconv.ParseBool(c.DefaultQuery("replace", "false"))
+	sym, n, err := fetcher.ImportKLines(symbol, klines, replace)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	resp := gin.H{"symbol": sym, "imported": n}
+	if n > 0 {
+		resp["first"], resp["last"] = klines[0].Date, klines[len(klines)-1].Date
+	}
+	c.JSON(http.StatusOK, resp)
+}
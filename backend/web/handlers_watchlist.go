@@ -0,0 +1,93 @@
+package web
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"go-stock-analyzer/backend/storage"
+	"go-stock-analyzer/backend/watchlist"
+
+	"github.com/gin-gonic/gin"
+)
+
+// POST /api/watchlist/import?format=blk|ebk|ths|csv&replace=false
+// 上传文件（multipart 字段 file 或直接作为 body）；format 为空时按文件扩展名判断。
+// 代码统一转换为 sh/sz/bj 前缀，名称取自证券主表；replace=true 时先清空现有自选股。
+// 文件中没有可识别的代码时返回 400；imported 为实际新增条数（已存在的不计）
+func ImportWatchlistHandler(c *gin.Context) {
+	format := c.Query("format")
+	var data []byte
+	var err error
+	if file, ferr := c.FormFile("file"); ferr == nil {
+		if format == "" {
+			format = watchlist.DetectFormat(file.Filename)
+		}
+		var f multipart.File
+		if f, err = file.Open(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		defer f.Close()
+		data, err = io.ReadAll(f)
+	} else {
+		data, err = io.ReadAll(c.Request.Body)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if format == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format required"})
+		return
+	}
+	entries, bad, err := watchlist.Parse(format, data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if bad == nil {
+		bad = []string{}
+	}
+	// 空文件或全部无法识别时拒绝，避免 replace=true 把现有自选股清空
+	if len(entries) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no valid symbols in file", "invalid": bad})
+		return
+	}
+	unknown := watchlist.ResolveNames(entries)
+	replace, _ := strconv.ParseBool(c.DefaultQuery("replace", "false"))
+	items := make([]storage.WatchStock, 0, len(entries))
+	for _, e := range entries {
+		items = append(items, storage.WatchStock{Symbol: e.Symbol, Name: e.Name})
+	}
+	n, err := storage.ImportWatchlist(items, replace)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"imported": n, "list": entries, "unknown": unknown, "invalid": bad})
+}
+
+// GET /api/watchlist/export?format=blk|ebk|ths|csv 导出自选股文件
+func ExportWatchlistHandler(c *gin.Context) {
+	format := c.DefaultQuery("format", watchlist.FormatCSV)
+	wl, err := storage.GetWatchlist()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	entries := make([]watchlist.Entry, 0, len(wl))
+	for _, w := range wl {
+		entries = append(entries, watchlist.Entry{Symbol: w.Symbol, Name: w.Name})
+	}
+	data, err := watchlist.Format(format, entries)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ext := map[string]string{watchlist.FormatBLK: "blk", watchlist.FormatEBK: "EBK", watchlist.FormatTHS: "txt", watchlist.FormatCSV: "csv"}[format]
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "watchlist."+ext))
+	c.Data(http.StatusOK, "application/octet-stream", data)
+}
@@ -1,12 +1,11 @@
 package web
 
 import (
-	"encoding/json"
-	"fmt"
+	"go-stock-analyzer/backend/fetcher"
 	"go-stock-analyzer/backend/realtime"
-	"io/ioutil"
+	"go-stock-analyzer/backend/storage"
+	"go-stock-analyzer/backend/upstream"
 	"net/http"
-	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -17,12 +16,40 @@ func RunServer() {
 
 	// API endpoints
 	r.GET("/api/stocks", GetStocksHandler)
+	r.GET("/api/stock_changes", GetStockChangesHandler)
+	r.GET("/api/universe", GetUniverseHandler)
 	r.GET("/api/watchlist", GetWatchlistHandler)
 	r.POST("/api/watchlist/add", AddWatchlistHandler)
 	r.DELETE("/api/watchlist/remove", RemoveWatchlistHandler)
+	r.POST("/api/watchlist/import", ImportWatchlistHandler)
+	r.GET("/api/watchlist/export", ExportWatchlistHandler)
 	r.GET("/api/kline", GetKLineHandler)
+	r.GET("/api/indicators", ListIndicatorsHandler)
+	r.GET("/api/chart_patterns", GetChartPatternsHandler)
+	r.GET("/api/levels", GetLevelsHandler)
+	r.GET("/api/kline/export", ExportKLineHandler)
+	r.POST("/api/kline/import", ImportKLineHandler)
+	r.POST("/api/tdx/import", ImportTDXDayHandler)
 	r.GET("/api/timeline", GetTimelineHandler)
+	r.GET("/api/index/list", ListIndexHandler)
+	r.GET("/api/index/kline", GetIndexKLineHandler)
+	r.GET("/api/index/compare", CompareIndexHandler)
+	r.GET("/api/data_quality", GetDataQualityHandler)
+	r.POST("/api/data_quality/check", CheckDataQualityHandler)
+	r.GET("/api/minute_kline", GetMinuteKLineHandler)
+	r.POST("/api/minute_kline/sync", SyncMinuteKLineHandler)
 	r.GET("/api/is_market_open", IsMarketOpenHandler)
+	r.GET("/api/calendar", GetCalendarHandler)
+	r.GET("/api/clock", GetClockHandler)
+	r.POST("/api/clock/advance", AdvanceClockHandler)
+	r.GET("/api/upstream/status", func(c *gin.Context) {
+		c.JSON(http.StatusOK, upstream.Default().Stats())
+	})
+
+	r.GET("/api/jobs", ListJobsHandler)
+	r.GET("/api/jobs/:name/status", JobStatusHandler)
+	r.POST("/api/jobs/:name/start", StartJobHandler)
+	r.POST("/api/jobs/:name/stop", StopJobHandler)
 
 	r.GET("/api/strategy/list", ListStrategiesHandler)
 	r.POST("/api/strategy/run", RunStrategyHandler)
@@ -45,63 +72,31 @@ func RunServer() {
 	r.Run(":8080")
 }
 
-// 新浪分时接口返回结构
-// [{"day":"2024-06-14","time":"09:30","price":"10.23","volume":"1234"}, ...]
-type SinaTimeline struct {
-	Time   string `json:"time"`
-	Price  string `json:"price"`
-	Volume string `json:"volume"`
-}
+// 分时数据点
 type Timeline struct {
 	Time   string  `json:"time"`
 	Price  float64 `json:"price"`
 	Volume float64 `json:"volume"`
 }
 
+// GET /api/timeline?symbol=sz000001 最近 48 根 5 分钟 K 线作为分时
 func GetTimelineHandler(c *gin.Context) {
 	symbol := c.Query("symbol")
 	if symbol == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "symbol required"})
 		return
 	}
-	url := fmt.Sprintf("http://money.finance.sina.com.cn/quotes_service/api/json_v2.php/CN_MarketData.getKLineData?symbol=%s&scale=5&datalen=48", symbol)
-	resp, err := http.Get(url)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
+	klines, err := fetcher.FetchMinuteKLine(symbol, 5, 48)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-	str := string(body)
-	str = strings.ReplaceAll(str, "'", "\"")
-	if !strings.HasPrefix(str, "[") {
-		c.JSON(http.StatusOK, []Timeline{})
-		return
-	}
-	// 新浪5分钟K线结构
-	type SinaKLine struct {
-		Day    string `json:"day"`
-		Open   string `json:"open"`
-		High   string `json:"high"`
-		Low    string `json:"low"`
-		Close  string `json:"close"`
-		Volume string `json:"volume"`
-	}
-	var klines []SinaKLine
-	if err := json.Unmarshal([]byte(str), &klines); err != nil {
 		c.JSON(http.StatusOK, []Timeline{})
 		return
 	}
+	// 顺带保存为 5 分钟线历史
+	_ = storage.SaveMinuteKLines(symbol, 5, klines)
 	out := make([]Timeline, 0, len(klines))
 	for _, k := range klines {
-		price, _ := strconv.ParseFloat(k.Close, 64)
-		volume, _ := strconv.ParseFloat(k.Volume, 64)
 		// 只取时间部分
-		t := k.Day
+		t := k.Date
 		if len(t) >= 8 {
 			// 兼容 "2025-09-30 09:35:00" 或 "09:35:00"
 			parts := strings.Split(t, " ")
@@ -109,7 +104,7 @@ func GetTimelineHandler(c *gin.Context) {
 				t = parts[1]
 			}
 		}
-		out = append(out, Timeline{Time: t, Price: price, Volume: volume})
+		out = append(out, Timeline{Time: t, Price: k.Close, Volume: k.Volume})
 	}
 	c.JSON(http.StatusOK, out)
 }
@@ -1,56 +1,113 @@
 package main
 
 import (
-	"fmt"
+	"flag"
+	"log"
 	"net/http"
-	"io/ioutil"
+	"net/url"
+	"strconv"
+	"strings"
+
 	"github.com/gin-gonic/gin"
+
+	"go-stock-analyzer/backend/datasource"
 )
 
-// GetStocksHandler 获取股票数据
+// stockapi 把本地 AKTools 封装为与后端一致的数据格式（storage.KLine / StockInfo / Quote），
+// symbol 统一使用 sz000001 / sh600000 格式。加 -stub 时在 AKTools 地址上启动离线替身服务。
+
+var src *datasource.AKToolsSource
+
+// GET /api/stocks 证券列表；兼容旧接口，带 symbol 时返回该股票的历史日线（同 /api/kline）
 func GetStocksHandler(c *gin.Context) {
-	// 获取请求中的股票代码
-	symbol := c.Query("symbol")
+	if c.Query("symbol") != "" {
+		GetKLineHandler(c)
+		return
+	}
+	list, err := src.FetchStockList()
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "无法获取股票列表：" + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// GET /api/kline?symbol=sz000001&days=120 不复权日线
+func GetKLineHandler(c *gin.Context) {
+	symbol := datasource.NormalizeSymbol(c.Query("symbol"))
 	if symbol == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "symbol is required"})
 		return
 	}
-
-	// 拼接请求URL
-	url := fmt.Sprintf("http://127.0.0.1:18080/api/public/stock_zh_a_hist?symbol=%s", symbol)
-
-	// 发起HTTP请求
-	resp, err := http.Get(url)
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "120"))
+	if days <= 0 {
+		days = 120
+	}
+	klines, err := src.FetchDailyKLine(symbol, days)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法连接到股票数据源：" + err.Error()})
+		c.JSON(http.StatusBadGateway, gin.H{"error": "无法获取股票数据：" + err.Error()})
 		return
 	}
-	defer resp.Body.Close()
+	c.JSON(http.StatusOK, klines)
+}
 
-	// 如果AKTools返回的状态码不是200，则返回错误
-	if resp.StatusCode != http.StatusOK {
-		c.JSON(resp.StatusCode, gin.H{"error": fmt.Sprintf("无法获取数据，状态码：%d", resp.StatusCode)})
+// GET /api/minute_kline?symbol=sz000001&scale=5&datalen=48 分钟线
+func GetMinuteKLineHandler(c *gin.Context) {
+	symbol := datasource.NormalizeSymbol(c.Query("symbol"))
+	if symbol == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "symbol is required"})
 		return
 	}
-
-	// 读取返回的Body内容
-	body, err := ioutil.ReadAll(resp.Body)
+	scale, _ := strconv.Atoi(c.DefaultQuery("scale", "5"))
+	datalen, _ := strconv.Atoi(c.DefaultQuery("datalen", "48"))
+	klines, err := src.FetchMinuteKLine(symbol, scale, datalen)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "无法读取股票数据：" + err.Error()})
+		c.JSON(http.StatusBadGateway, gin.H{"error": "无法获取分钟数据：" + err.Error()})
 		return
 	}
+	c.JSON(http.StatusOK, klines)
+}
 
-	// 将获取到的数据返回
-	c.Data(http.StatusOK, "application/json", body)
+// GET /api/quotes?symbols=sz000001,sh600000 实时快照
+func GetQuotesHandler(c *gin.Context) {
+	symbols := strings.Split(c.Query("symbols"), ",")
+	quotes, err := src.FetchQuotes(symbols)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "无法获取行情：" + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, quotes)
 }
 
 func main() {
+	addr := flag.String("addr", ":8088", "listen address")
+	aktools := flag.String("aktools", datasource.DefaultAKToolsURL, "AKTools base url")
+	stub := flag.Bool("stub", false, "serve an offline AKTools stub on the -aktools address")
+	flag.Parse()
+
+	if *stub {
+		u, err := url.Parse(*aktools)
+		if err != nil {
+			log.Fatalf("invalid aktools url: %v", err)
+		}
+		go func() {
+			log.Printf("AKTools stub listening on %s", u.Host)
+			if err := http.ListenAndServe(u.Host, datasource.NewAKToolsStub()); err != nil {
+				log.Fatalf("stub server failed: %v", err)
+			}
+		}()
+	}
+	src = datasource.NewAKToolsSource(*aktools)
+
 	// 创建Gin引擎
 	r := gin.Default()
 
 	// 注册路由
 	r.GET("/api/stocks", GetStocksHandler)
+	r.GET("/api/kline", GetKLineHandler)
+	r.GET("/api/minute_kline", GetMinuteKLineHandler)
+	r.GET("/api/quotes", GetQuotesHandler)
 
 	// 启动服务器
-	r.Run(":8088")
+	r.Run(*addr)
 }
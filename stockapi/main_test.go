@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"go-stock-analyzer/backend/datasource"
+	"go-stock-analyzer/backend/storage"
+)
+
+func newTestRouter(t *testing.T) (*gin.Engine, *httptest.Server) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	stub := httptest.NewServer(datasource.NewAKToolsStub())
+	t.Cleanup(stub.Close)
+	src = datasource.NewAKToolsSource(stub.URL)
+	r := gin.New()
+	r.GET("/api/stocks", GetStocksHandler)
+	r.GET("/api/kline", GetKLineHandler)
+	return r, stub
+}
+
+func TestStocksSymbolReturnsHistory(t *testing.T) {
+	r, stub := newTestRouter(t)
+
+	// 上游原始行：成交量单位为手
+	resp, err := http.Get(stub.URL + "/api/public/stock_zh_a_hist?symbol=000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw []map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	rawVol := map[string]float64{}
+	for _, row := range raw {
+		rawVol[row["日期"].(string)[:10]] = row["成交量"].(float64)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/stocks?symbol=000001&days=30", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body.String())
+	}
+	var klines []storage.KLine
+	if err := json.Unmarshal(w.Body.Bytes(), &klines); err != nil {
+		t.Fatal(err)
+	}
+	if len(klines) != 30 {
+		t.Fatalf("got %d klines, want 30", len(klines))
+	}
+	for _, k := range klines {
+		if k.Code != "sz000001" {
+			t.Fatalf("code %q, want sz000001", k.Code)
+		}
+		if len(k.Date) != 10 {
+			t.Fatalf("date %q not normalised", k.Date)
+		}
+		if v, ok := rawVol[k.Date]; !ok || k.Volume != v*100 {
+			t.Fatalf("%s volume %v, raw %v (ok=%v)", k.Date, k.Volume, v, ok)
+		}
+	}
+}
+
+func TestStocksListWithoutSymbol(t *testing.T) {
+	r, _ := newTestRouter(t)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/stocks", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body.String())
+	}
+	var list []storage.StockInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list) == 0 {
+		t.Fatal("empty stock list")
+	}
+	for _, s := range list {
+		if datasource.NormalizeSymbol(s.Symbol) != s.Symbol {
+			t.Fatalf("symbol %q not normalised", s.Symbol)
+		}
+	}
+}
+
+func TestKLineRejectsInvalidSymbol(t *testing.T) {
+	r, _ := newTestRouter(t)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/stocks?symbol=abc", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status %d, want 400", w.Code)
+	}
+}